@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -31,7 +33,7 @@ func main() {
 	//Read SQL file
 	sqlBytes, err := os.ReadFile(*filePath)
 	if err != nil {
-		log.Fatalf("Failed to read migration file %v", *filePath, err)
+		log.Fatalf("Failed to read migration file %v: %v", *filePath, err)
 	}
 	sqlContent := string(sqlBytes)
 
@@ -41,7 +43,7 @@ func main() {
 	checkSum := hex.EncodeToString(hash.Sum(nil))
 
 	//Parse SQL into individual statement
-	statements, err := sqlparser.SplitStatementToPieces(sqlContent)
+	statements, err := splitStatements(sqlContent)
 	if err != nil {
 		log.Fatalf("Error splitting SQL statements %v", err)
 		statements = []string{sqlContent} //Fallback
@@ -67,7 +69,7 @@ func main() {
 	//Open DB connection
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
-		log.Fatalf("Failed to open database connection %v", *filePath, err)
+		log.Fatalf("Failed to open database connection %v: %v", dsn, err)
 	}
 	//Close DB connection when the migration done
 	defer db.Close()
@@ -138,4 +140,39 @@ func main() {
 	}
 
 	log.Printf("Migration %s applied successfully with checksum %s and %d applied steps.\n", migrationName, checkSum, steps)
-}
\ No newline at end of file
+}
+
+// triggerStart matches the start of a CREATE TRIGGER statement, whose body
+// holds statements of its own
+var triggerStart = regexp.MustCompile(`(?is)^\s*(--[^\n]*\n\s*)*CREATE\s+((TEMP|TEMPORARY)\s+)?TRIGGER\b`)
+
+// splitStatements splits a migration into its statements. The parser also
+// splits trigger bodies on their ;, so those pieces are joined back up to
+// the END of the trigger.
+func splitStatements(sqlContent string) ([]string, error) {
+	pieces, err := sqlparser.SplitStatementToPieces(sqlContent)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	var trigger []string
+	for _, piece := range pieces {
+		switch {
+		case trigger != nil:
+			trigger = append(trigger, piece)
+			if strings.EqualFold(strings.TrimSpace(piece), "END") {
+				statements = append(statements, strings.Join(trigger, ";"))
+				trigger = nil
+			}
+		case triggerStart.MatchString(piece):
+			trigger = []string{piece}
+		default:
+			statements = append(statements, piece)
+		}
+	}
+	if trigger != nil {
+		return nil, fmt.Errorf("missing END in trigger: %s", strings.TrimSpace(trigger[0]))
+	}
+	return statements, nil
+}
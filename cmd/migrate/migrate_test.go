@@ -0,0 +1,73 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "statements",
+			sql:  "CREATE TABLE a (id INTEGER);\nINSERT INTO a VALUES (1);\n",
+			want: []string{"CREATE TABLE a (id INTEGER)", "INSERT INTO a VALUES (1)"},
+		},
+		{
+			name: "semicolons in strings",
+			sql:  "INSERT INTO a (name) VALUES ('x;y');",
+			want: []string{"INSERT INTO a (name) VALUES ('x;y')"},
+		},
+		{
+			name: "trigger body is kept whole",
+			sql: "CREATE TRIGGER IF NOT EXISTS a_delete AFTER DELETE ON a BEGIN\n" +
+				"    DELETE FROM b WHERE a_id = old.id;\n" +
+				"    DELETE FROM c WHERE a_id = old.id;\n" +
+				"END;\n" +
+				"INSERT INTO a VALUES (1);",
+			want: []string{
+				"CREATE TRIGGER IF NOT EXISTS a_delete AFTER DELETE ON a BEGIN\n" +
+					"    DELETE FROM b WHERE a_id = old.id;\n" +
+					"    DELETE FROM c WHERE a_id = old.id;\n" +
+					"END",
+				"INSERT INTO a VALUES (1)",
+			},
+		},
+		{
+			name: "temporary trigger after a comment",
+			sql:  "-- clean up\ncreate temp trigger t after insert on a begin select 1; end;",
+			want: []string{"-- clean up\ncreate temp trigger t after insert on a begin select 1; end"},
+		},
+		{
+			name:    "trigger without END",
+			sql:     "CREATE TRIGGER t AFTER INSERT ON a BEGIN SELECT 1;",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitStatements(tt.sql)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("splitStatements() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitStatements(): %v", err)
+			}
+			for i := range got {
+				got[i] = strings.TrimSpace(got[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,234 +1,1098 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
 
+	"github.com/ryuux05/task-cli/presenter"
+	"github.com/ryuux05/task-cli/server"
 	"github.com/ryuux05/task-cli/task"
+	"github.com/ryuux05/task-cli/tui"
+	"golang.org/x/term"
 )
 
+// app is what commands need to run: the service and how to show its results
+type app struct {
+	service task.TaskService
+	out     presenter.Presenter
+	// output is the --output format out was created for
+	output string
+	// interactive is set when commands are read from the interactive prompt
+	interactive bool
+}
+
+// errExit is returned by the exit command to end interactive mode
+var errExit = errors.New("exit")
+
+// commands are the commands shared by single-shot and interactive mode
+var commands *registry
+
+func init() {
+	commands = newRegistry(
+		addCommand,
+		listCommand,
+		meCommand,
+		startCommand,
+		doneCommand,
+		updateCommand,
+		assignCommand,
+		unassignCommand,
+		deleteCommand,
+		viewCommand,
+		viewAllCommand,
+		searchCommand,
+		commentCommand,
+		membersCommand,
+		addMemberCommand,
+		memberCommand,
+		doctorCommand,
+		reportCommand,
+		statsCommand,
+		standupCommand,
+		digestCommand,
+		switchUserCommand,
+		loginCommand,
+		logoutCommand,
+		connectCommand,
+		tuiCommand,
+		serveCommand,
+		exportCommand,
+		completionCommand,
+		helpCommand,
+		exitCommand,
+		completeWordsCommand,
+	)
+}
+
 // 🔹 Executes a single CLI command
-func executeCommand(service task.TaskService, args []string) {
-	command := args[0]
+func executeCommand(ctx context.Context, a *app, args []string) error {
+	return commands.dispatch(ctx, a, args)
+}
+
+var addCommand = &command{
+	name:           "add",
+	args:           "<task_name>",
+	help:           "Add a new task with the given name",
+	minArgs:        1,
+	flagCompletion: map[string]completion{"c": completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		assignees := fs.String("c", "", "Members assigned to this task, separated by commas")
+		priority := fs.String("p", "none", "Priority for this task (none, low, medium, high)")
+		description := fs.String("d", "", "Description for this task")
+		due := fs.String("due", "", "Due date (YYYY-MM-DD, today or tomorrow)")
+		createMember := fs.Bool("create-member", false, "Add assignees that are not members yet")
 
-	switch command {
-	case "add":
-		if len(args) < 2 {
-			fmt.Println("Usage: task add <task_description> [-c <collaborator>]")
-			return
+		return func(ctx context.Context, a *app, args []string) error {
+			priorityValue, err := task.ParsePriority(*priority)
+			if err != nil {
+				return err
+			}
+			if *createMember {
+				if err := createMembers(ctx, a, strings.Split(*assignees, ",")); err != nil {
+					return err
+				}
+			}
+
+			added, err := a.service.AddTask(ctx, task.NewTaskSchema{
+				Name:        strings.Join(args, " "),
+				Assignees:   strings.Split(*assignees, ","),
+				Priority:    priorityValue,
+				Description: *description,
+				DueDate:     *due,
+			})
+			if err != nil {
+				return fmt.Errorf("error adding task: %w", unknownMemberHint(err))
+			}
+			return a.out.Task(added, fmt.Sprintf("Task %d added successfully.", added.Id))
 		}
+	},
+}
 
-		// Create a dedicated FlagSet for the add command
-		addCmd := flag.NewFlagSet("add", flag.ContinueOnError)
-		collaborator := addCmd.String("c", "", "Collaborator for this task")
+var listCommand = &command{
+	name:    "list",
+	aliases: []string{"ls"},
+	args:    "[filter]",
+	help: `List open tasks, or completed (-c) or all (-a) tasks
+The filter is a list of conditions such as status:pending owner:me created>2026-10-01`,
+	flagCompletion: map[string]completion{"assignee": completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		completed := fs.Bool("c", false, "Show only completed tasks")
+		all := fs.Bool("a", false, "Show all tasks")
+		sort := fs.String("sort", "", "Sort fields, e.g. created,-priority")
+		limit := fs.Int("limit", 0, "Maximum number of tasks to show")
+		offset := fs.Int("offset", 0, "Number of tasks to skip")
+		columns := fs.String("columns", "", "Columns to show, e.g. id,name,status,owner,assignees")
+		assignee := fs.String("assignee", "", "Show only tasks assigned to this member, or me")
 
-		// Find where the task name ends and flags begin
-		taskNameParts := []string{}
-		flagStartIdx := -1
+		return func(ctx context.Context, a *app, args []string) error {
+			return listTasks(ctx, a, task.ListOptions{
+				Completed: *completed,
+				All:       *all,
+				Filter:    strings.Join(args, " "),
+				Assignee:  *assignee,
+				Sort:      *sort,
+				Limit:     *limit,
+				Offset:    *offset,
+			}, *columns)
+		}
+	},
+}
 
-		for i, arg := range args[1:] {
-			if arg == "-c" {
-				flagStartIdx = i + 1
-				break
+var meCommand = &command{
+	name: "me",
+	help: `Show your open tasks and the tasks you completed this week
+They are grouped into overdue tasks, blocked tasks, tasks you own and tasks you
+collaborate on.`,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			work, err := a.service.MyWork(ctx)
+			if err != nil {
+				return fmt.Errorf("error loading your work: %w", err)
 			}
-			taskNameParts = append(taskNameParts, arg)
+			return a.out.MyWork(work)
 		}
+	},
+}
 
-		// Process flags if any
-		if flagStartIdx != -1 {
-			err := addCmd.Parse(args[flagStartIdx+1:])
+var doneCommand = &command{
+	name:          "done",
+	args:          "<task_id>",
+	help:          "Mark a task as done",
+	minArgs:       1,
+	argCompletion: []completion{completePendingTaskID},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
 			if err != nil {
-				fmt.Println("Error parsing flags:", err)
-				return
+				return err
 			}
+			done, err := a.service.CompleteTask(ctx, id)
+			if err != nil {
+				return fmt.Errorf("error marking task %d as done: %w", id, err)
+			}
+			return a.out.Task(done, fmt.Sprintf("Task %d marked as done successfully.", id))
 		}
+	},
+}
 
-		// Combine task name parts
-		taskName := strings.Join(taskNameParts, " ")
-		fmt.Printf("Adding task: '%s' with collaborator: '%s'\n", taskName, *collaborator)
+var startCommand = &command{
+	name:          "start",
+	args:          "<task_id>",
+	help:          "Mark a task as in progress",
+	minArgs:       1,
+	argCompletion: []completion{completePendingTaskID},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			started, err := a.service.UpdateTask(ctx, task.UpdateTaskSchema{ID: id, Status: task.StatusInProgress})
+			if err != nil {
+				return fmt.Errorf("error starting task %d: %w", id, err)
+			}
+			return a.out.Task(started, fmt.Sprintf("Task %d marked as in progress.", id))
+		}
+	},
+}
 
-		err := service.HandleAddTask(taskName, *collaborator)
-		if err != nil {
-			fmt.Printf("Error adding task: %v\n", err)
-			return
+var updateCommand = &command{
+	name:           "update",
+	args:           "<task_id> [new_name]",
+	help:           "Update a task. Only the given fields are changed.",
+	minArgs:        1,
+	argCompletion:  []completion{completeTaskID, completeNone},
+	flagCompletion: map[string]completion{"c": completeMember, "collaborator": completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		name := fs.String("name", "", "New task name")
+		status := fs.String("status", "", "New task status (pending, in_progress, blocked or done)")
+		done := fs.Bool("done", false, "Mark as completed")
+		collaborator := fs.String("c", "", "Assign a member to this task")
+		fs.StringVar(collaborator, "collaborator", "", "Assign a member to this task")
+		priority := fs.String("p", "", "Priority for this task (none, low, medium, high)")
+		description := fs.String("d", "", "Description for this task")
+		due := fs.String("due", "", "Due date (YYYY-MM-DD, today, tomorrow, or none to remove it)")
+		createMember := fs.Bool("create-member", false, "Add the member if they are not a member yet")
+
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+
+			updateData := task.UpdateTaskSchema{
+				ID:           id,
+				Name:         *name,
+				Status:       *status,
+				Completed:    done,
+				Collaborator: *collaborator,
+			}
+			if updateData.Name == "" {
+				updateData.Name = strings.Join(args[1:], " ")
+			}
+
+			if *priority != "" {
+				priorityValue, err := task.ParsePriority(*priority)
+				if err != nil {
+					return err
+				}
+				updateData.Priority = &priorityValue
+			}
+
+			// Only change the description and due date when they were given
+			fs.Visit(func(f *flag.Flag) {
+				switch f.Name {
+				case "d":
+					updateData.Description = description
+				case "due":
+					updateData.DueDate = due
+				}
+			})
+
+			if updateData.Name == "" && updateData.Status == "" && !*done && updateData.Collaborator == "" &&
+				updateData.Priority == nil && updateData.Description == nil && updateData.DueDate == nil {
+				return fmt.Errorf("at least one field to update must be provided")
+			}
+
+			if *createMember {
+				if err := createMembers(ctx, a, []string{updateData.Collaborator}); err != nil {
+					return err
+				}
+			}
+
+			updated, err := a.service.UpdateTask(ctx, updateData)
+			if err != nil {
+				return fmt.Errorf("error updating task: %w", unknownMemberHint(err))
+			}
+			return a.out.Task(updated, "Task updated successfully")
 		}
+	},
+}
 
-	case "list":
-		completed := flag.Bool("c", false, "Show only completed tasks")
-		all := flag.Bool("a", false, "Show all tasks")
-		flag.CommandLine.Parse(os.Args[2:])
-		service.HandleList(*completed, *all)
+var assignCommand = &command{
+	name:           "assign",
+	args:           "<task_id> <member>...",
+	help:           "Assign members to a task, or change their role. me is the current member.",
+	minArgs:        2,
+	argCompletion:  []completion{completeTaskID, completeMember},
+	flagCompletion: map[string]completion{"role": completeRole},
+	setup: func(fs *flag.FlagSet) handler {
+		role := fs.String("role", task.RoleAssignee, "Role of the members: "+strings.Join(task.Roles, ", "))
+		createMember := fs.Bool("create-member", false, "Add members that are not members yet")
 
-	case "done":
-		if len(args) < 2 {
-			fmt.Println("Usage: task done <task_id>")
-			return
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			if *createMember {
+				if err := createMembers(ctx, a, args[1:]); err != nil {
+					return err
+				}
+			}
+			assigned, err := a.service.AssignTask(ctx, id, *role, args[1:])
+			if err != nil {
+				return fmt.Errorf("error assigning task: %w", unknownMemberHint(err))
+			}
+			return a.out.Task(assigned, fmt.Sprintf("Task %d assigned to %s as %s.", id, strings.Join(args[1:], ", "), *role))
 		}
-		id, err := strconv.Atoi(args[1])
-		if err != nil {
-			fmt.Println("Invalid task ID.")
-			return
+	},
+}
+
+var unassignCommand = &command{
+	name:          "unassign",
+	args:          "<task_id> <member>...",
+	help:          "Remove members from a task. me is the current member.",
+	minArgs:       2,
+	argCompletion: []completion{completeTaskID, completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			unassigned, err := a.service.UnassignTask(ctx, id, args[1:])
+			if err != nil {
+				return fmt.Errorf("error unassigning task: %w", err)
+			}
+			return a.out.Task(unassigned, fmt.Sprintf("Removed %s from task %d.", strings.Join(args[1:], ", "), id))
+		}
+	},
+}
+
+var deleteCommand = &command{
+	name:          "delete",
+	aliases:       []string{"rm"},
+	args:          "<task_id>",
+	help:          "Delete a task and its comments",
+	minArgs:       1,
+	argCompletion: []completion{completeTaskID},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			if err := a.service.DeleteTask(ctx, id); err != nil {
+				return fmt.Errorf("error deleting task: %w", err)
+			}
+			return a.out.Message("Task %d deleted.", id)
 		}
-		service.HandleDone(id)
+	},
+}
 
-	case "delete":
-		if len(args) < 2 {
-			fmt.Println("Usage: task delete <task_id>")
-			return
+var viewCommand = &command{
+	name:          "view",
+	args:          "<task_id>",
+	help:          "View details of a task",
+	minArgs:       1,
+	argCompletion: []completion{completeTaskID},
+	setup: func(fs *flag.FlagSet) handler {
+		format := fs.String("format", "html", "Output format (html or text)")
+		viewOptions := htmlViewFlags(fs)
+
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			return viewTask(ctx, a, id, *format, viewOptions())
 		}
-		id, err := strconv.Atoi(args[1])
-		if err != nil {
-			fmt.Println("Invalid task ID.")
-			return
+	},
+}
+
+var viewAllCommand = &command{
+	name: "view-all",
+	help: "View all tasks",
+	setup: func(fs *flag.FlagSet) handler {
+		format := fs.String("format", "html", "Output format (html or text)")
+		viewOptions := htmlViewFlags(fs)
+
+		return func(ctx context.Context, a *app, args []string) error {
+			return viewAllTasks(ctx, a, *format, viewOptions())
 		}
-		service.HandleDelete(id)
+	},
+}
 
-	case "update":
-		if len(args) < 3 {
-			fmt.Println("Usage: task update <task_id> <new_name>")
-			return
+var searchCommand = &command{
+	name:    "search",
+	args:    "<query>",
+	help:    "Search task names, descriptions and comments",
+	minArgs: 1,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			results, err := a.service.SearchTasks(ctx, strings.Join(args, " "))
+			if err != nil {
+				return fmt.Errorf("error searching tasks: %w", err)
+			}
+			return a.out.SearchResults(results)
 		}
-		id, err := strconv.Atoi(args[1])
-		if err != nil {
-			fmt.Println("Invalid task ID.")
-			return
+	},
+}
+
+var commentCommand = &command{
+	name:          "comment",
+	args:          "<task_id> <comment>",
+	help:          "Comment on a task",
+	minArgs:       2,
+	argCompletion: []completion{completeTaskID, completeNone},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			id, err := parseTaskID(args[0])
+			if err != nil {
+				return err
+			}
+			if err := a.service.AddComment(ctx, id, strings.Join(args[1:], " ")); err != nil {
+				return fmt.Errorf("error adding comment: %w", err)
+			}
+			return a.out.Message("Comment added successfully")
 		}
-		updateCmd := flag.NewFlagSet("update", flag.ExitOnError)
-		completed := updateCmd.Bool("c", false, "Mark as completed")
-		collaborator := updateCmd.String("collaborator", "", "Collaborator for this task")
-		updateCmd.Parse(args[2:])
+	},
+}
 
-		// Set up the completion status
-		var isCompleted *bool = completed
+var membersCommand = &command{
+	name: "members",
+	help: "List all members",
+	setup: func(fs *flag.FlagSet) handler {
+		return listMembers
+	},
+}
 
-		updateData := task.UpdateTaskSchema{
-			ID:           id,
-			Name:         updateCmd.Arg(0),
-			Completed:    isCompleted,
-			Collaborator: *collaborator,
+var addMemberCommand = &command{
+	name:    "add-member",
+	args:    "<member_name>",
+	help:    "Add a new member with the given name",
+	minArgs: 1,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if err := a.service.AddMember(ctx, args[0]); err != nil {
+				return fmt.Errorf("error adding member: %w", err)
+			}
+			return a.out.Message("Member added successfully")
 		}
+	},
+}
 
-		service.HandleUpdate(updateData)
+var memberCommand = &command{
+	name: "member",
+	args: "<action> <member_name> [...]",
+	help: `Manage a member
+  member role <name> <role>     Change the member's role: ` + strings.Join(task.MemberRoles, ", ") + `
+  member password <name>        Set the member's password, or remove it with -remove
+  member rename <name> <new>    Rename the member in all tasks and comments
+  member deactivate <name>      Deactivate a member who left, handing their open
+                                tasks to -reassign-to
+  member activate <name>        Undo deactivate
+  member remove <name>          Remove a member added by mistake, handing their
+                                tasks to -reassign-to
+Admins can do anything, members can change tasks but only delete their own,
+and viewers can only read. Only admins can manage members, but members can
+set their own password. Members with a password must log in to act.`,
+	minArgs:        1,
+	argCompletion:  []completion{completeMemberAction, completeMember, completeMemberRole},
+	flagCompletion: map[string]completion{"reassign-to": completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		remove := fs.Bool("remove", false, "Remove the password instead of setting one")
+		reassignTo := fs.String("reassign-to", "", "Member who takes over the tasks of a deactivated or removed member, or me")
 
-	case "view":
-		if len(args) < 2 {
-			fmt.Println("Usage: task view <task_id>")
-			return
+		return func(ctx context.Context, a *app, args []string) error {
+			switch args[0] {
+			case "password":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: member password [-remove] <member_name>")
+				}
+				password := ""
+				if !*remove {
+					var err error
+					password, err = readNewPassword()
+					if err != nil {
+						return err
+					}
+				}
+				if err := a.service.SetPassword(ctx, args[1], password); err != nil {
+					return fmt.Errorf("error setting password: %w", err)
+				}
+				if *remove {
+					return a.out.Message("Removed the password of %s.", args[1])
+				}
+				return a.out.Message("Password set for %s.", args[1])
+			case "role":
+				if len(args) != 3 {
+					return fmt.Errorf("usage: member role <member_name> <role>")
+				}
+				if err := a.service.SetMemberRole(ctx, args[1], args[2]); err != nil {
+					return fmt.Errorf("error changing role: %w", err)
+				}
+				return a.out.Message("%s is now %s.", args[1], strings.ToLower(args[2]))
+			case "rename":
+				if len(args) != 3 {
+					return fmt.Errorf("usage: member rename <member_name> <new_name>")
+				}
+				if err := a.service.RenameMember(ctx, args[1], args[2]); err != nil {
+					return fmt.Errorf("error renaming member: %w", err)
+				}
+				return a.out.Message("Renamed %s to %s.", args[1], strings.TrimSpace(args[2]))
+			case "deactivate":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: member deactivate <member_name> [-reassign-to <member_name>]")
+				}
+				reassigned, err := a.service.DeactivateMember(ctx, args[1], *reassignTo)
+				if err != nil {
+					return fmt.Errorf("error deactivating member: %w", err)
+				}
+				return a.out.Message("Deactivated %s%s.", args[1], reassignedText(reassigned, *reassignTo))
+			case "activate":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: member activate <member_name>")
+				}
+				if err := a.service.ActivateMember(ctx, args[1]); err != nil {
+					return fmt.Errorf("error activating member: %w", err)
+				}
+				return a.out.Message("Activated %s.", args[1])
+			case "remove":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: member remove <member_name> [-reassign-to <member_name>]")
+				}
+				reassigned, err := a.service.RemoveMember(ctx, args[1], *reassignTo)
+				if err != nil {
+					return fmt.Errorf("error removing member: %w", err)
+				}
+				return a.out.Message("Removed %s%s.", args[1], reassignedText(reassigned, *reassignTo))
+			}
+			return fmt.Errorf("unknown member action: %s (supported: role, password, rename, deactivate, activate, remove)", args[0])
 		}
-		id, err := strconv.Atoi(args[1])
-		if err != nil {
-			fmt.Println("Invalid task ID.")
-			return
+	},
+}
+
+// reassignedText describes how many tasks were handed to another member
+func reassignedText(count int, to string) string {
+	if to == "" {
+		return ""
+	}
+	if count == 1 {
+		return fmt.Sprintf(" and reassigned 1 task to %s", to)
+	}
+	return fmt.Sprintf(" and reassigned %d tasks to %s", count, to)
+}
+
+var doctorCommand = &command{
+	name: "doctor",
+	help: "Check the database for task owners and assignees that are not members",
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			orphans, err := a.service.FindOrphans(ctx)
+			if err != nil {
+				return fmt.Errorf("error checking members: %w", err)
+			}
+			if err := a.out.OrphanedMembers(orphans); err != nil {
+				return err
+			}
+			if len(orphans) > 0 {
+				return fmt.Errorf("found %d references to unknown members", len(orphans))
+			}
+			return nil
 		}
-		viewCmd := flag.NewFlagSet("view", flag.ContinueOnError)
-		format := viewCmd.String("format", "html", "Output format (html or text)")
-		if len(os.Args) > 3 {
-			viewCmd.Parse(os.Args[3:])
+	},
+}
+
+var reportCommand = &command{
+	name: "report",
+	args: "workload",
+	help: `Show a report about the team
+  report workload  Open tasks per member: owned and collaborating, by priority
+                   and status, overdue, and their average age
+Use --output csv for a spreadsheet, or -format html for a page in the browser.`,
+	minArgs:       1,
+	argCompletion: []completion{completeReport},
+	setup: func(fs *flag.FlagSet) handler {
+		format := fs.String("format", "text", "Output format (text or html)")
+		viewOptions := htmlViewFlags(fs)
+
+		return func(ctx context.Context, a *app, args []string) error {
+			if args[0] != "workload" {
+				return fmt.Errorf("unknown report: %s (supported: workload)", args[0])
+			}
+			workload, err := a.service.WorkloadReport(ctx)
+			if err != nil {
+				return fmt.Errorf("error building report: %w", err)
+			}
+			if *format == "html" && a.output == presenter.OutputText {
+				return presenter.GenerateAndDisplayWorkload(os.Stdout, workload, viewOptions())
+			}
+			return a.out.Workload(workload)
 		}
+	},
+}
 
-		service.HandleViewTask(id, *format)
+var statsCommand = &command{
+	name: "stats",
+	help: `Show task throughput, lead and cycle times, and a burndown
+Counts the tasks created and completed per day or week, and the open tasks at
+the end of each period.
+Use --output csv or json to export the series for charting.`,
+	flagCompletion: map[string]completion{"member": completeMember, "interval": completeInterval},
+	setup: func(fs *flag.FlagSet) handler {
+		since := fs.String("since", task.DefaultStatsSince, "Period to cover: a number of days or weeks such as 30d or 4w, or YYYY-MM-DD")
+		member := fs.String("member", "", "Only count the tasks assigned to this member, or me")
+		interval := fs.String("interval", task.IntervalDay, "Period of the series (day or week)")
 
-	case "view-all":
-		viewAllCmd := flag.NewFlagSet("view-all", flag.ContinueOnError)
-		format := viewAllCmd.String("format", "html", "Output format (html or text)")
-		if len(os.Args) > 2 {
-			viewAllCmd.Parse(os.Args[2:])
+		return func(ctx context.Context, a *app, args []string) error {
+			stats, err := a.service.Stats(ctx, task.StatsOptions{
+				Since:    *since,
+				Member:   *member,
+				Interval: *interval,
+			})
+			if err != nil {
+				return fmt.Errorf("error computing stats: %w", err)
+			}
+			return a.out.Stats(stats)
 		}
+	},
+}
 
-		service.HandleViewAllTasks(*format)
+var standupCommand = &command{
+	name: "standup",
+	help: `Show what a member did since the last working day and is working on
+Lists the tasks they completed since then and their tasks in progress and
+blocked.
+-template renders it with your own Go text/template instead of the default.`,
+	flagCompletion: map[string]completion{"member": completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		member := fs.String("member", "me", "Member to show, or me")
+		templatePath := fs.String("template", "", "Go text/template file to render the standup with")
+
+		return func(ctx context.Context, a *app, args []string) error {
+			standup, err := a.service.Standup(ctx, *member)
+			if err != nil {
+				return fmt.Errorf("error building standup: %w", err)
+			}
+			return a.out.Standup(standup, *templatePath)
+		}
+	},
+}
 
-	case "members":
-		service.HandleListMembers()
+var digestCommand = &command{
+	name: "digest",
+	help: `Write a Markdown summary of the team's activity
+Lists the tasks completed, started and created in the period, the tasks blocked
+now, and what each member did.
+-template renders it with your own Go text/template instead of the default.`,
+	setup: func(fs *flag.FlagSet) handler {
+		week := fs.Bool("week", false, "Cover the last 7 days (the default)")
+		since := fs.String("since", "", "Period to cover instead: a number of days or weeks such as 14d or 2w, or YYYY-MM-DD")
+		templatePath := fs.String("template", "", "Go text/template file to render the digest with")
 
-	case "add-member":
-		if len(args) < 2 {
-			fmt.Println("Usage: task add-member <member_name>")
-			return
+		return func(ctx context.Context, a *app, args []string) error {
+			if *week && *since != "" {
+				return fmt.Errorf("-week and -since cannot be used together")
+			}
+			digest, err := a.service.Digest(ctx, *since)
+			if err != nil {
+				return fmt.Errorf("error building digest: %w", err)
+			}
+			return a.out.Digest(digest, *templatePath)
 		}
+	},
+}
 
-		memberName := args[1]
-		err := service.HandleAddMember(memberName)
-		if err != nil {
-			fmt.Println("Error adding member:", err)
-			return
+var loginCommand = &command{
+	name: "login",
+	args: "<member_name>",
+	help: `Log in as a member that has a password
+The password is read from the terminal, or from stdin when it isn't one. The
+session is kept on this machine and expires after -for.`,
+	minArgs:       1,
+	argCompletion: []completion{completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		ttl := fs.Duration("for", task.DefaultSessionTTL, "How long the session lasts, at most "+task.MaxSessionTTL.String())
+
+		return func(ctx context.Context, a *app, args []string) error {
+			password, err := readPassword("Password: ")
+			if err != nil {
+				return err
+			}
+			expires, err := a.service.Login(ctx, args[0], password, *ttl)
+			if err != nil {
+				return fmt.Errorf("error logging in: %w", err)
+			}
+			return a.out.Message("Logged in as %s until %s.", args[0], expires.Local().Format("2006-01-02 15:04"))
 		}
-		fmt.Println("Member added successfully")
+	},
+}
 
-	case "switch-user":
-		if len(args) < 2 {
-			fmt.Println("Usage: task switch-user <member_name>")
-			return
+var logoutCommand = &command{
+	name: "logout",
+	help: "End the login session on this machine",
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if err := a.service.Logout(ctx); err != nil {
+				return fmt.Errorf("error logging out: %w", err)
+			}
+			return a.out.Message("Logged out.")
 		}
+	},
+}
 
-		memberName := args[1]
-		err := service.HandleSetCurrentMember(memberName)
-		if err != nil {
-			fmt.Println("Error switching user:", err)
-			return
-		}
-		fmt.Printf("Switched to user: %s\n", memberName)
-
-	case "connect":
-		connectCmd := flag.NewFlagSet("connect", flag.ExitOnError)
-		host := connectCmd.String("host", "", "Database host address")
-		port := connectCmd.String("port", "", "Database port")
-		dbName := connectCmd.String("db", "", "Database name")
-		username := connectCmd.String("user", "", "Database username")
-		password := connectCmd.String("pass", "", "Database password")
-		team := connectCmd.String("team", "", "Team name (if connecting to a team database)")
-		url := connectCmd.String("url", "", "Database connection URL (overrides individual connection parameters)")
-
-		// Show usage if no arguments provided
-		if len(os.Args) < 3 {
-			fmt.Println("Usage: task connect [options]")
-			fmt.Println("Options:")
-			connectCmd.PrintDefaults()
-			return
-		}
-
-		connectCmd.Parse(os.Args[2:])
-
-		// Create a ConnectionDetails object
-		details := task.ConnectionDetails{}
-
-		// Check connection methods in order of precedence: URL > Team > Individual Parameters
-		if *url != "" {
-			details.URL = *url
-		} else if *team != "" {
-			details.Team = *team
-		} else if *host != "" || *port != "" || *dbName != "" || *username != "" {
-			// At least one individual parameter was specified, check if we have all required ones
-			if *host == "" || *port == "" || *dbName == "" || *username == "" {
-				fmt.Println("Error: Missing required connection parameters.")
-				fmt.Println("For an external database connection, you must provide:")
-				fmt.Println("  -host, -port, -db, and -user")
-				return
-			}
-
-			details.Host = *host
-			details.Port = *port
-			details.Database = *dbName
-			details.Username = *username
-			details.Password = *password
-		} else {
-			fmt.Println("Error: No connection method specified.")
-			fmt.Println("You must provide one of:")
-			fmt.Println("  -url: A database connection URL")
-			fmt.Println("  -team: A team name for a team-specific database")
-			fmt.Println("  -host, -port, -db, -user: Individual connection parameters")
-			return
-		}
-
-		// Connect to the database
-		err := service.HandleConnect(details)
+var switchUserCommand = &command{
+	name:          "switch-user",
+	args:          "<member_name>",
+	help:          "Switch to another user",
+	minArgs:       1,
+	argCompletion: []completion{completeMember},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if err := a.service.SetCurrentMember(ctx, args[0]); err != nil {
+				return fmt.Errorf("error switching user: %w", err)
+			}
+			return a.out.Message("Switched to user: %s", args[0])
+		}
+	},
+}
+
+var connectCommand = &command{
+	name: "connect",
+	help: `Connect to an external database
+Use one of these methods:
+  1. URL: connect -url <database_url>
+  2. Team: connect -team <team_name>
+  3. Individual parameters: connect -host <host> -port <port> -db <database> -user <username> -pass <password>`,
+	setup: func(fs *flag.FlagSet) handler {
+		url := fs.String("url", "", "Database connection URL (overrides individual connection parameters)")
+		team := fs.String("team", "", "Team name (if connecting to a team database)")
+		host := fs.String("host", "", "Database host address")
+		port := fs.String("port", "", "Database port")
+		dbName := fs.String("db", "", "Database name")
+		username := fs.String("user", "", "Database username")
+		password := fs.String("pass", "", "Database password")
+
+		return func(ctx context.Context, a *app, args []string) error {
+			details := task.ConnectionDetails{}
+
+			// Check connection methods in order of precedence: URL > Team > Individual Parameters
+			if *url != "" {
+				details.URL = *url
+			} else if *team != "" {
+				details.Team = *team
+			} else if *host != "" || *port != "" || *dbName != "" || *username != "" {
+				// At least one individual parameter was specified, check if we have all required ones
+				if *host == "" || *port == "" || *dbName == "" || *username == "" {
+					return fmt.Errorf("missing required connection parameters: an external database connection needs -host, -port, -db and -user")
+				}
+
+				details.Host = *host
+				details.Port = *port
+				details.Database = *dbName
+				details.Username = *username
+				details.Password = *password
+			} else {
+				return fmt.Errorf("no connection method specified: use -url, -team, or -host, -port, -db and -user")
+			}
+
+			return connect(ctx, a, details)
+		}
+	},
+}
+
+var tuiCommand = &command{
+	name: "tui",
+	help: `Open the full-screen terminal UI
+It shows a filterable task list with a detail pane and a kanban board, and
+reloads the tasks every few seconds. The keys are listed at the bottom.`,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if a.interactive {
+				return fmt.Errorf("the terminal UI can't be opened from the interactive prompt; run 'task tui' from your shell")
+			}
+			return tui.Run(ctx, a.service)
+		}
+	},
+}
+
+var serveCommand = &command{
+	name: "serve",
+	help: `Serve the HTML views with a JSON API on a local web server
+The task list page can add, edit, complete and delete tasks through the API:
+  GET/POST /api/tasks, GET/PATCH/DELETE /api/tasks/<id>, GET/POST /api/members
+Stop the server with Ctrl-C.`,
+	setup: func(fs *flag.FlagSet) handler {
+		addr := fs.String("addr", server.DefaultAddr, "Address to listen on")
+
+		return func(ctx context.Context, a *app, args []string) error {
+			srv := server.New(a.service)
+			listener, err := net.Listen("tcp", *addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %v", *addr, err)
+			}
+
+			// Ctrl-C stops the server, also at the interactive prompt
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+			defer stop()
+
+			a.out.Message("Serving tasks on http://%s (press Ctrl-C to stop)", listener.Addr())
+			return srv.Serve(ctx, listener)
+		}
+	},
+}
+
+var exportCommand = &command{
+	name: "export",
+	args: "html",
+	help: `Export the board as a static HTML site
+It writes index.html with all tasks, a page per task and per member, and the
+assets they need. The links are relative, so the directory can be shared as it is.`,
+	minArgs:       1,
+	argCompletion: []completion{completeExportFormat},
+	setup: func(fs *flag.FlagSet) handler {
+		out := fs.String("out", "site", "Directory to write the site to")
+
+		return func(ctx context.Context, a *app, args []string) error {
+			if args[0] != "html" {
+				return fmt.Errorf("unsupported export format: %s (supported: html)", args[0])
+			}
+			return exportSite(ctx, a, *out)
+		}
+	},
+}
+
+var completionCommand = &command{
+	name: "completion",
+	args: "bash|zsh|fish",
+	help: `Print a shell completion script
+Load it in the current shell with:
+  bash: source <(task completion bash)
+  zsh:  source <(task completion zsh)
+  fish: task completion fish | source`,
+	minArgs:       1,
+	argCompletion: []completion{completeShell},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			return commands.writeCompletionScript(os.Stdout, args[0])
+		}
+	},
+}
+
+// completeWordsCommand is run by the completion scripts. Its arguments are
+// the words of the command line after "task", ending with the word being
+// completed, and it prints the candidates as "text<tab>hint" lines.
+var completeWordsCommand = &command{
+	name:   "__complete",
+	args:   "-- [words] <word>",
+	help:   "Print completions for a command line",
+	hidden: true,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if len(args) == 0 {
+				args = []string{""}
+			}
+			previous, word := args[:len(args)-1], args[len(args)-1]
+			for _, c := range commands.candidates(ctx, a, previous, word) {
+				fmt.Printf("%s\t%s\n", c.Text, strings.Join(strings.Fields(c.Hint), " "))
+			}
+			return nil
+		}
+	},
+}
+
+var helpCommand = &command{
+	name:          "help",
+	aliases:       []string{"h"},
+	args:          "[command]",
+	help:          "Show the available commands, or the usage of a command",
+	argCompletion: []completion{completeCommand},
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			if len(args) == 0 {
+				commands.printHelp(os.Stdout, a.interactive)
+				return nil
+			}
+			cmd, ok := commands.lookup(args[0], a.interactive)
+			if !ok {
+				return fmt.Errorf("unknown command: %s", args[0])
+			}
+			cmd.printUsage(os.Stdout)
+			return nil
+		}
+	},
+}
+
+var exitCommand = &command{
+	name:        "exit",
+	aliases:     []string{"quit"},
+	help:        "Exit the program",
+	interactive: true,
+	setup: func(fs *flag.FlagSet) handler {
+		return func(ctx context.Context, a *app, args []string) error {
+			fmt.Println("Goodbye!")
+			return errExit
+		}
+	},
+}
+
+// parseTaskID parses a task ID argument
+func parseTaskID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("invalid task ID: %s", arg)
+	}
+	return id, nil
+}
+
+// listTasks lists tasks, showing the given comma separated columns
+func listTasks(ctx context.Context, a *app, opts task.ListOptions, columns string) error {
+	selected, err := task.ParseColumns(columns)
+	if err != nil {
+		return fmt.Errorf("invalid columns: %w", err)
+	}
+
+	tasks, err := a.service.ListTasks(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return a.out.Tasks(tasks, selected)
+}
+
+// htmlViewFlags defines the flags of the commands that write an HTML view
+// and returns a function giving their values
+func htmlViewFlags(fs *flag.FlagSet) func() presenter.ViewOptions {
+	out := fs.String("out", "", "Write the HTML view to this file")
+	open := fs.Bool("open", true, "Open the HTML view in the default browser")
+	noOpen := fs.Bool("no-open", false, "Don't open the HTML view, only print where it was written")
+
+	return func() presenter.ViewOptions {
+		return presenter.ViewOptions{Out: *out, Open: *open && !*noOpen}
+	}
+}
+
+// viewTask shows a task. The html format writes it to a file and opens it in
+// the browser unless a machine-readable --output format was requested.
+func viewTask(ctx context.Context, a *app, id int, format string, opts presenter.ViewOptions) error {
+	detail, err := a.service.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if format == "html" && a.output == presenter.OutputText {
+		return presenter.GenerateAndDisplayHTML(os.Stdout, *detail, opts)
+	}
+	return a.out.TaskDetail(detail)
+}
+
+// viewAllTasks shows every task, see viewTask
+func viewAllTasks(ctx context.Context, a *app, format string, opts presenter.ViewOptions) error {
+	tasks, err := a.service.ListTasks(ctx, task.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	if format == "html" && a.output == presenter.OutputText && len(tasks) > 0 {
+		return presenter.GenerateAndDisplayTaskList(os.Stdout, tasks, opts)
+	}
+	return a.out.Tasks(tasks, nil)
+}
+
+// exportSite writes every task with its comments and every member to a
+// static site in dir
+func exportSite(ctx context.Context, a *app, dir string) error {
+	tasks, err := a.service.ListTasks(ctx, task.ListOptions{All: true})
+	if err != nil {
+		return err
+	}
+
+	details := make([]task.TaskDetail, 0, len(tasks))
+	for _, t := range tasks {
+		detail, err := a.service.GetTask(ctx, t.Id)
 		if err != nil {
-			fmt.Println(err)
+			return err
 		}
+		details = append(details, *detail)
+	}
+
+	members, err := a.service.ListMembers(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing members: %w", err)
+	}
+
+	if err := presenter.ExportSite(dir, details, members); err != nil {
+		return fmt.Errorf("error exporting site: %w", err)
+	}
+	return a.out.Message("Exported %d tasks to %s", len(details), dir)
+}
+
+// listMembers lists the members, marking the current one
+func listMembers(ctx context.Context, a *app, args []string) error {
+	members, err := a.service.ListMembers(ctx)
+	if err != nil {
+		return fmt.Errorf("error listing members: %w", err)
+	}
+
+	current, err := a.service.CurrentMember(ctx)
+	if err != nil && !errors.Is(err, task.ErrNoCurrentMember) {
+		return err
+	}
+	return a.out.Members(members, current)
+}
+
+// createMembers adds names that are not members yet, for --create-member.
+// Empty names and "me" are skipped.
+func createMembers(ctx context.Context, a *app, names []string) error {
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "me" {
+			continue
+		}
+		if err := a.service.AddMember(ctx, name); err != nil {
+			return fmt.Errorf("error adding member: %w", err)
+		}
+	}
+	return nil
+}
+
+// unknownMemberHint tells how to add a member that doesn't exist yet
+func unknownMemberHint(err error) error {
+	if errors.Is(err, task.ErrUnknownMember) {
+		return fmt.Errorf("%w\nUse --create-member to add them as a new member.", err)
+	}
+	return err
+}
+
+// connect switches to another database and asks for a username when the
+// database has no current member yet
+func connect(ctx context.Context, a *app, details task.ConnectionDetails) error {
+	if err := a.service.Connect(ctx, details); err != nil {
+		return err
+	}
+	a.out.Message("Successfully connected to external database!")
+
+	member, err := a.service.CurrentMember(ctx)
+	if errors.Is(err, task.ErrNoCurrentMember) {
+		fmt.Println("No username set for this database. Let's set one up.")
+		member, err = promptForUsername(ctx, a.service)
+	}
+	if err != nil {
+		return fmt.Errorf("error setting up member: %w", err)
+	}
+
+	return a.out.Message("Connected as: %s", member)
+}
+
+// readPassword reads a password without echoing it when stdin is a
+// terminal, or a line from stdin otherwise
+func readPassword(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %v", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return string(password), nil
+}
+
+// readNewPassword reads a new password, asking for it twice on a terminal
+func readNewPassword() (string, error) {
+	password, err := readPassword("New password: ")
+	if err != nil || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return password, err
+	}
+	again, err := readPassword("Repeat password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != again {
+		return "", fmt.Errorf("passwords don't match")
+	}
+	return password, nil
+}
+
+// promptForUsername asks for a name on stdin and makes it the current member
+func promptForUsername(ctx context.Context, service task.TaskService) (string, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Print("Enter your name: ")
+	name, err := reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read input: %v", err)
+	}
 
-	default:
-		fmt.Println("Unknown command:", command)
+	name = strings.TrimSpace(name)
+	if err := service.SetCurrentMember(ctx, name); err != nil {
+		return "", err
 	}
+	return name, nil
 }
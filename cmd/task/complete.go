@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ryuux05/task-cli/lineedit"
+	"github.com/ryuux05/task-cli/presenter"
+	"github.com/ryuux05/task-cli/task"
+)
+
+// completion says what kind of value an argument or flag takes, so it can be
+// completed
+type completion int
+
+const (
+	completeNone completion = iota
+	completeTaskID
+	completePendingTaskID
+	completeMember
+	completeCommand
+	completeOutputFormat
+	completeShell
+	completeExportFormat
+	completeRole
+	completeMemberAction
+	completeMemberRole
+	completeReport
+	completeInterval
+)
+
+// completer returns the tab completion function for interactive mode
+func (r *registry) completer(ctx context.Context, a *app) lineedit.Completer {
+	return func(line string, pos int) ([]lineedit.Candidate, int) {
+		before := []rune(line)[:pos]
+
+		// Only the command after the last ; matters
+		segment := before[lastCommandStart(before):]
+
+		start := len(segment)
+		for start > 0 && !unicode.IsSpace(segment[start-1]) {
+			start--
+		}
+		word := string(segment[start:])
+		start += pos - len(segment)
+
+		words, err := splitCommandLine(string(segment[:len(segment)-len([]rune(word))]))
+		if err != nil {
+			return nil, start
+		}
+		var previous []string
+		if len(words) > 0 {
+			previous = words[0]
+		}
+
+		return r.candidates(ctx, a, previous, word), start
+	}
+}
+
+// candidates returns the completions for word, given the words before it
+func (r *registry) candidates(ctx context.Context, a *app, previous []string, word string) []lineedit.Candidate {
+	// Global flags can appear anywhere
+	if n := len(previous); n > 0 && strings.TrimLeft(previous[n-1], "-") == "output" && strings.HasPrefix(previous[n-1], "-") {
+		return completeValues(ctx, a, completeOutputFormat, word)
+	}
+	var args []string
+	for i := 0; i < len(previous); i++ {
+		if name := strings.TrimLeft(previous[i], "-"); strings.HasPrefix(previous[i], "-") && (name == "output" || name == "verbose") {
+			if name == "output" {
+				i++
+			}
+			continue
+		}
+		args = append(args, previous[i])
+	}
+
+	if len(args) == 0 {
+		return completeValues(ctx, a, completeCommand, word)
+	}
+
+	cmd, ok := r.lookup(args[0], a.interactive)
+	if !ok {
+		return nil
+	}
+	fs := cmd.flagSet()
+	cmd.setup(fs)
+
+	if strings.HasPrefix(word, "-") {
+		var candidates []lineedit.Candidate
+		fs.VisitAll(func(f *flag.Flag) {
+			if strings.HasPrefix("-"+f.Name, word) {
+				candidates = append(candidates, lineedit.Candidate{Text: "-" + f.Name, Hint: f.Usage})
+			}
+		})
+		return candidates
+	}
+
+	// Work out whether word is a flag value or which positional argument it is
+	position := 0
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			position++
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil || hasValue || isBoolFlag(f) {
+			continue
+		}
+		if i == len(args)-1 {
+			return completeValues(ctx, a, cmd.flagCompletion[name], word)
+		}
+		i++
+	}
+
+	if len(cmd.argCompletion) == 0 {
+		return nil
+	}
+	if position >= len(cmd.argCompletion) {
+		position = len(cmd.argCompletion) - 1
+	}
+	return completeValues(ctx, a, cmd.argCompletion[position], word)
+}
+
+// completeValues returns the values of a kind that start with word
+func completeValues(ctx context.Context, a *app, kind completion, word string) []lineedit.Candidate {
+	var candidates []lineedit.Candidate
+	add := func(text, hint string) {
+		if strings.HasPrefix(text, word) {
+			candidates = append(candidates, lineedit.Candidate{Text: text, Hint: hint})
+		}
+	}
+
+	switch kind {
+	case completeTaskID, completePendingTaskID:
+		tasks, err := a.service.ListTasks(ctx, task.ListOptions{All: kind == completeTaskID})
+		if err != nil {
+			return nil
+		}
+		for _, t := range tasks {
+			add(strconv.Itoa(t.Id), t.Name)
+		}
+	case completeMember:
+		members, err := a.service.ListMembers(ctx)
+		if err != nil {
+			return nil
+		}
+		// Deactivated members can't be assigned or switched to
+		for _, m := range members {
+			if m.Active() {
+				add(m.Name, "")
+			}
+		}
+	case completeCommand:
+		var names []string
+		for name, cmd := range commands.byName {
+			if name == cmd.name && !cmd.hidden && (a.interactive || !cmd.interactive) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(name, firstLine(commands.byName[name].help))
+		}
+		// Aliases are only offered once they are the only way to match
+		if len(candidates) == 0 {
+			for name, cmd := range commands.byName {
+				if !cmd.hidden && (a.interactive || !cmd.interactive) {
+					add(name, "")
+				}
+			}
+		}
+	case completeOutputFormat:
+		for _, format := range presenter.OutputFormats {
+			add(format, "")
+		}
+	case completeShell:
+		for _, shell := range completionShells {
+			add(shell, "")
+		}
+	case completeExportFormat:
+		add("html", "Static HTML site")
+	case completeRole:
+		for _, role := range task.Roles {
+			add(role, "")
+		}
+	case completeMemberAction:
+		add("role", "Change a member's role")
+		add("password", "Set or remove a member's password")
+		add("rename", "Rename a member")
+		add("deactivate", "Deactivate a member who left")
+		add("activate", "Reactivate a member")
+		add("remove", "Remove a member added by mistake")
+	case completeReport:
+		add("workload", "Open tasks per member")
+	case completeInterval:
+		add(task.IntervalDay, "")
+		add(task.IntervalWeek, "")
+	case completeMemberRole:
+		for _, role := range task.MemberRoles {
+			add(role, "")
+		}
+	}
+	return candidates
+}
+
+// lastCommandStart returns the index after the last unquoted ; in line
+func lastCommandStart(line []rune) int {
+	start := 0
+	var quote rune
+	for i := 0; i < len(line); i++ {
+		switch r := line[i]; {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else if r == '\\' && quote == '"' {
+				i++
+			}
+		case r == '\\':
+			i++
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ';':
+			start = i + 1
+		}
+	}
+	return start
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
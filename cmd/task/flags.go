@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ryuux05/task-cli/presenter"
+)
+
+// globalOptions are the flags accepted by every command
+type globalOptions struct {
+	output  string
+	verbose bool
+}
+
+// extractGlobalFlags removes the global flags from args, wherever they appear
+// before a "--", and returns them with the remaining arguments. Both -flag
+// and --flag forms are accepted, with the value separated by a space or "=".
+func extractGlobalFlags(args []string) (globalOptions, []string, error) {
+	opts := globalOptions{output: presenter.OutputText}
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		switch name {
+		case "output":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return opts, nil, fmt.Errorf("flag needs an argument: %s", arg)
+				}
+				i++
+				value = args[i]
+			}
+			if err := presenter.ValidateOutputFormat(value); err != nil {
+				return opts, nil, err
+			}
+			opts.output = value
+		case "verbose":
+			opts.verbose = true
+			if hasValue {
+				verbose, err := strconv.ParseBool(value)
+				if err != nil {
+					return opts, nil, fmt.Errorf("invalid value %q for flag %s", value, arg)
+				}
+				opts.verbose = verbose
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	return opts, rest, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    globalOptions
+		rest    []string
+		wantErr bool
+	}{
+		{name: "none", args: []string{"list"}, want: globalOptions{output: "text"}, rest: []string{"list"}},
+		{name: "verbose", args: []string{"--verbose", "list"}, want: globalOptions{output: "text", verbose: true}, rest: []string{"list"}},
+		{name: "verbose after command", args: []string{"list", "-verbose"}, want: globalOptions{output: "text", verbose: true}, rest: []string{"list"}},
+		{name: "verbose true", args: []string{"--verbose=1", "list"}, want: globalOptions{output: "text", verbose: true}, rest: []string{"list"}},
+		{name: "verbose false", args: []string{"--verbose=false", "list"}, want: globalOptions{output: "text"}, rest: []string{"list"}},
+		{name: "invalid verbose", args: []string{"--verbose=yes", "list"}, wantErr: true},
+		{name: "output", args: []string{"--output", "json", "list"}, want: globalOptions{output: "json"}, rest: []string{"list"}},
+		{name: "output with equals", args: []string{"list", "-output=csv"}, want: globalOptions{output: "csv"}, rest: []string{"list"}},
+		{name: "output without value", args: []string{"list", "--output"}, wantErr: true},
+		{name: "unknown output", args: []string{"--output=xml", "list"}, wantErr: true},
+		{
+			name: "flags after -- are kept",
+			args: []string{"add", "--", "--verbose"},
+			want: globalOptions{output: "text"},
+			rest: []string{"add", "--", "--verbose"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, rest, err := extractGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("extractGlobalFlags(%q) = %+v, want an error", tt.args, opts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractGlobalFlags(%q): %v", tt.args, err)
+			}
+			if opts != tt.want || !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("extractGlobalFlags(%q) = %+v, %q, want %+v, %q", tt.args, opts, rest, tt.want, tt.rest)
+			}
+		})
+	}
+}
@@ -1,314 +1,113 @@
 package main
 
 import (
-	"bufio"
-	"flag"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"strconv"
+	"path/filepath"
 	"strings"
 
-	"github.com/ryuux05/task-cli/task"
+	"github.com/ryuux05/task-cli/lineedit"
+	"github.com/ryuux05/task-cli/presenter"
 )
 
-func startInteractiveMode(service task.TaskService) {
+// historyFile is where interactive input is kept between sessions, relative
+// to the home directory
+const historyFile = ".task_cli_history"
+
+func startInteractiveMode(ctx context.Context, a *app) {
 	fmt.Println("Task Manager CLI")
 	fmt.Println("Type 'help' for commands or 'exit' to quit.")
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for {
-		fmt.Print("> ") // CLI Prompt
-		scanner.Scan()
-		input := scanner.Text()
-		args := strings.Fields(input)
+	a.interactive = true
+	editor := lineedit.NewEditor(loadHistory(), commands.completer(ctx, a))
 
-		if len(args) == 0 {
+	for {
+		commandLines, err := readCommands(editor)
+		if errors.Is(err, lineedit.ErrInterrupted) {
 			continue
 		}
-
-		command := args[0]
-
-		switch command {
-		case "add":
-			addCmd := flag.NewFlagSet("add", flag.ContinueOnError)
-			addCmd.Usage = func() {
-				fmt.Println("Usage: add <task_name> [-c <collaborator>]")
-				fmt.Println("Add a new task with the given name")
-			}
-			addCollaborator := addCmd.String("c", "", "Collaborator for this task")
-
-			err := addCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing add command:", err)
-				continue
-			}
-
-			if addCmd.NArg() < 1 {
-				fmt.Println("Error: Task name is required")
-				addCmd.Usage()
-				continue
-			}
-
-			taskName := addCmd.Arg(0)
-			err = service.HandleAddTask(taskName, *addCollaborator)
-			if err != nil {
-				fmt.Println("Error adding task:", err)
-			}
-			continue
-
-		case "list":
-			listCmd := flag.NewFlagSet("list", flag.ExitOnError)
-			completed := listCmd.Bool("c", false, "Show only completed tasks")
-			all := listCmd.Bool("a", false, "Show all tasks")
-			flag.CommandLine.Parse(os.Args[1:])
-			service.HandleList(*completed, *all)
-
-		case "done":
-			if len(args) < 2 {
-				fmt.Println("Usage: task done <task_id>")
-				return
-			}
-			id, err := strconv.Atoi(args[1])
-			if err != nil {
-				fmt.Println("Invalid task ID.")
-				return
-			}
-			service.HandleDone(id)
-
-		case "update":
-			updateCmd := flag.NewFlagSet("update", flag.ContinueOnError)
-			updateCmd.Usage = func() {
-				fmt.Println("Usage: update <id> -name <new_name> -status <new_status> [-c <collaborator>]")
-				fmt.Println("Update a task")
-			}
-			updateName := updateCmd.String("name", "", "New task name")
-			updateStatus := updateCmd.String("status", "", "New task status (pending/done)")
-			updateCollaborator := updateCmd.String("c", "", "Collaborator for this task")
-
-			err := updateCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing update command:", err)
-				continue
+		if err != nil {
+			if errors.Is(err, errIncomplete) {
+				fmt.Fprintln(os.Stderr, "Error: unexpected end of input")
 			}
+			return
+		}
 
-			if updateCmd.NArg() < 1 {
-				fmt.Println("Error: Task ID is required")
-				updateCmd.Usage()
-				continue
-			}
-
-			idStr := updateCmd.Arg(0)
-
-			if *updateName == "" && *updateStatus == "" && *updateCollaborator == "" {
-				fmt.Println("Error: At least one field to update must be provided")
-				updateCmd.Usage()
-				continue
-			}
-
-			err = service.HandleUpdateTask(idStr, *updateName, *updateStatus, *updateCollaborator)
-			if err != nil {
-				fmt.Println("Error updating task:", err)
-			} else {
-				fmt.Println("Task updated successfully")
-			}
-			continue
-
-		case "view":
-			if len(args) < 2 {
-				fmt.Println("Usage: task view <task_id>")
-				return
-			}
-			id, err := strconv.Atoi(args[1])
-			if err != nil {
-				fmt.Println("Invalid task ID.")
+		for _, args := range commandLines {
+			if errors.Is(runInteractiveCommand(ctx, a, args), errExit) {
 				return
 			}
-			viewCmd := flag.NewFlagSet("view", flag.ContinueOnError)
-			format := viewCmd.String("format", "html", "Output format (html or text)")
-			// Parse from the remaining interactive arguments
-			if len(args) > 2 {
-				viewCmd.Parse(args[2:])
-			}
-
-			service.HandleViewTask(id, *format)
-
-		case "view-all":
-			viewAllCmd := flag.NewFlagSet("view-all", flag.ContinueOnError)
-			format := viewAllCmd.String("format", "html", "Output format (html or text)")
-			viewAllCmd.Parse(args[1:])
-
-			service.HandleViewAllTasks(*format)
-
-		case "connect":
-			connectCmd := flag.NewFlagSet("connect", flag.ContinueOnError)
-			connectCmd.Usage = func() {
-				fmt.Println("Usage: connect [options]")
-				fmt.Println("Connect to an external database using one of these methods:")
-				fmt.Println("  1. URL: connect -url <database_url>")
-				fmt.Println("  2. Team: connect -team <team_name>")
-				fmt.Println("  3. Individual parameters: connect -host <host> -port <port> -db <database> -user <username> -pass <password>")
-			}
-
-			// Connection options
-			url := connectCmd.String("url", "", "Database connection URL (overrides individual connection parameters)")
-			team := connectCmd.String("team", "", "Team name (for predefined connections)")
-			host := connectCmd.String("host", "", "Database host")
-			port := connectCmd.String("port", "", "Database port")
-			dbName := connectCmd.String("db", "", "Database name")
-			user := connectCmd.String("user", "", "Database username")
-			pass := connectCmd.String("pass", "", "Database password")
-
-			err := connectCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing connect command:", err)
-				continue
-			}
-
-			// Create connection details
-			details := task.ConnectionDetails{
-				URL:      *url,
-				Team:     *team,
-				Host:     *host,
-				Port:     *port,
-				Database: *dbName,
-				Username: *user,
-				Password: *pass,
-			}
-
-			// Connect to the database
-			err = service.HandleConnect(details)
-			if err != nil {
-				fmt.Println(err)
-			}
-
-		case "delete":
-			if len(args) < 2 {
-				fmt.Println("Usage: delete <task_id>")
-				continue
-			}
-			id, err := strconv.Atoi(args[1])
-			if err != nil {
-				fmt.Println("Invalid task ID.")
-				continue
-			}
-			service.HandleDelete(id)
-
-		case "members":
-			membersCmd := flag.NewFlagSet("members", flag.ContinueOnError)
-			membersCmd.Usage = func() {
-				fmt.Println("Usage: members")
-				fmt.Println("List all members")
-			}
-
-			err := membersCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing members command:", err)
-				continue
-			}
-
-			err = service.HandleListMembers()
-			if err != nil {
-				fmt.Println("Error listing members:", err)
-			}
-			continue
-
-		case "add-member":
-			addMemberCmd := flag.NewFlagSet("add-member", flag.ContinueOnError)
-			addMemberCmd.Usage = func() {
-				fmt.Println("Usage: add-member <member_name>")
-				fmt.Println("Add a new member with the given name")
-			}
-
-			err := addMemberCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing add-member command:", err)
-				continue
-			}
-
-			if addMemberCmd.NArg() < 1 {
-				fmt.Println("Error: Member name is required")
-				addMemberCmd.Usage()
-				continue
-			}
-
-			memberName := addMemberCmd.Arg(0)
-			err = service.HandleAddMember(memberName)
-			if err != nil {
-				fmt.Println("Error adding member:", err)
-			} else {
-				fmt.Println("Member added successfully")
-			}
-			continue
+		}
+	}
+}
 
-		case "switch-user":
-			switchUserCmd := flag.NewFlagSet("switch-user", flag.ContinueOnError)
-			switchUserCmd.Usage = func() {
-				fmt.Println("Usage: switch-user <member_name>")
-				fmt.Println("Switch to another user")
-			}
+// loadHistory loads the history of previous sessions. History is optional,
+// so problems are only reported.
+func loadHistory() *lineedit.History {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	history, err := lineedit.LoadHistory(filepath.Join(home, historyFile))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	}
+	return history
+}
 
-			err := switchUserCmd.Parse(args[1:])
-			if err != nil {
-				if err == flag.ErrHelp {
-					continue
-				}
-				fmt.Println("Error parsing switch-user command:", err)
-				continue
-			}
+// readCommands reads input until it forms complete commands, prompting for
+// more when a line ends inside quotes or with a backslash. The input is
+// added to the history as one entry.
+func readCommands(editor *lineedit.Editor) ([][]string, error) {
+	var input string
+	prompt := "> " // CLI Prompt
+	for {
+		line, err := editor.ReadLine(prompt)
+		if errors.Is(err, io.EOF) && input != "" {
+			return nil, errIncomplete
+		}
+		if err != nil {
+			return nil, err
+		}
+		input += line
 
-			if switchUserCmd.NArg() < 1 {
-				fmt.Println("Error: Member name is required")
-				switchUserCmd.Usage()
-				continue
+		commandLines, err := splitCommandLine(input)
+		if !errors.Is(err, errIncomplete) {
+			if herr := editor.History.Add(strings.ReplaceAll(input, "\\\n", "")); herr != nil {
+				fmt.Fprintln(os.Stderr, "Warning:", herr)
 			}
+			return commandLines, err
+		}
 
-			memberName := switchUserCmd.Arg(0)
-			err = service.HandleSetCurrentMember(memberName)
-			if err != nil {
-				fmt.Println("Error switching user:", err)
-			} else {
-				fmt.Printf("Switched to user: %s\n", memberName)
-			}
-			continue
+		input += "\n"
+		prompt = "... "
+	}
+}
 
-		case "help":
-			fmt.Println("Available commands:")
-			fmt.Println("  add <task_name> [-c <collaborator>] - Add a new task")
-			fmt.Println("  list [--all] [--completed] - List tasks")
-			fmt.Println("  done <id> - Mark a task as done")
-			fmt.Println("  update <id> -name <new_name> -status <new_status> [-c <collaborator>] - Update a task")
-			fmt.Println("  view <id> [-format html|text] - View details of a task")
-			fmt.Println("  view-all [-format html|text] - View all tasks")
-			fmt.Println("  delete <id> - Delete a task")
-			fmt.Println("  members - List all members")
-			fmt.Println("  switch-space <name> - Switch to another task space")
-			fmt.Println("  connect -host <host> -port <port> -db <dbname> -user <username> -pass <password> - Connect to an external database")
-			fmt.Println("  connect -team <team_name> - Connect to a team database")
-			fmt.Println("  connect -url <connection_url> - Connect using a database URL")
-			fmt.Println("  help - Show this help text")
-			fmt.Println("  exit - Exit the program")
+// runInteractiveCommand runs one command, printing its error. --output given
+// on a command only applies to that command.
+func runInteractiveCommand(ctx context.Context, a *app, args []string) error {
+	opts, args, err := extractGlobalFlags(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return err
+	}
+	if len(args) == 0 {
+		return nil
+	}
 
-		case "exit":
-			fmt.Println("Goodbye!")
-			return
+	line := *a
+	if opts.output != presenter.OutputText {
+		line.output = opts.output
+		line.out, _ = presenter.New(os.Stdout, opts.output)
+	}
 
-		default:
-			fmt.Println("Unknown command:", command)
-		}
+	err = commands.dispatch(ctx, &line, args)
+	if err != nil && !errors.Is(err, errExit) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 	}
+	return err
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// errIncomplete is returned by splitCommandLine when the input ends inside
+// quotes or with a line continuation, and more input is needed
+var errIncomplete = errors.New("incomplete input")
+
+// splitCommandLine splits interactive input into commands and their words,
+// following shell quoting rules:
+//
+//   - words are separated by unquoted whitespace
+//   - single quotes keep everything up to the next single quote literally
+//   - double quotes group words; inside them a backslash only escapes " and \
+//   - outside quotes a backslash escapes the next character, and a backslash
+//     before a newline joins the two lines
+//   - an unquoted ; ends a command, so several commands can share one line
+//
+// Empty commands are dropped. A "--" word is kept so the command can stop
+// parsing flags there.
+func splitCommandLine(input string) ([][]string, error) {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	// inWord is set once the current word has started, so "" is kept as an
+	// empty argument
+	inWord := false
+
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = nil
+		}
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, errIncomplete
+			}
+			i++
+			if runes[i] == '\n' {
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord = true
+
+		case r == '\'':
+			end := indexRune(runes, '\'', i+1)
+			if end < 0 {
+				return nil, errIncomplete
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\') {
+					i++
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, errIncomplete
+			}
+
+		case r == ';':
+			endCommand()
+
+		case unicode.IsSpace(r):
+			endWord()
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endCommand()
+
+	return commands, nil
+}
+
+// indexRune returns the index of the first r in runes at or after from, or -1
+func indexRune(runes []rune, r rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][]string
+		wantErr error
+	}{
+		{name: "empty", input: ""},
+		{name: "only whitespace", input: " \t\n"},
+		{
+			name:  "words",
+			input: "  list   -a\tdone 3 ",
+			want:  [][]string{{"list", "-a", "done", "3"}},
+		},
+		{
+			name:  "single quotes are literal",
+			input: `add 'fix "the" \n bug'`,
+			want:  [][]string{{"add", `fix "the" \n bug`}},
+		},
+		{
+			name:  "double quotes group words",
+			input: `add "write the docs"`,
+			want:  [][]string{{"add", "write the docs"}},
+		},
+		{
+			name:  "double quotes only escape quote and backslash",
+			input: `add "a \"b\" c\\d \n"`,
+			want:  [][]string{{"add", `a "b" c\d \n`}},
+		},
+		{
+			name:  "backslash escapes outside quotes",
+			input: `add fix\ bug \'x\' \;`,
+			want:  [][]string{{"add", "fix bug", "'x'", ";"}},
+		},
+		{
+			name:  "line continuation",
+			input: "add long\\\nname",
+			want:  [][]string{{"add", "longname"}},
+		},
+		{
+			name:  "quotes join adjacent text",
+			input: `list name~"a b"'c'd`,
+			want:  [][]string{{"list", "name~a bcd"}},
+		},
+		{
+			name:  "empty quotes are an empty argument",
+			input: `update 3 -description "" ''`,
+			want:  [][]string{{"update", "3", "-description", "", ""}},
+		},
+		{
+			name:  "semicolons separate commands",
+			input: "done 1; done 2;;list",
+			want:  [][]string{{"done", "1"}, {"done", "2"}, {"list"}},
+		},
+		{
+			name:  "quoted semicolons are kept",
+			input: `add "a; b" 'c;d'`,
+			want:  [][]string{{"add", "a; b", "c;d"}},
+		},
+		{
+			name:  "double dash is kept",
+			input: "add -- -p high",
+			want:  [][]string{{"add", "--", "-p", "high"}},
+		},
+		{
+			name:  "unicode",
+			input: `add "café ☕" naïve`,
+			want:  [][]string{{"add", "café ☕", "naïve"}},
+		},
+		{name: "unterminated single quote", input: "add 'fix", wantErr: errIncomplete},
+		{name: "unterminated double quote", input: `add "fix`, wantErr: errIncomplete},
+		{name: "escaped closing quote", input: `add "fix\"`, wantErr: errIncomplete},
+		{name: "trailing backslash", input: `add fix\`, wantErr: errIncomplete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitCommandLine(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("splitCommandLine(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitCommandLine(%q): %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandLine(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
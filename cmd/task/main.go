@@ -1,28 +1,67 @@
 package main
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
 
+	"github.com/ryuux05/task-cli/internal/logging"
+	"github.com/ryuux05/task-cli/presenter"
 	"github.com/ryuux05/task-cli/storage"
 	"github.com/ryuux05/task-cli/task"
 )
 
 func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	ctx := context.Background()
+
+	opts, args, err := extractGlobalFlags(os.Args[1:])
+	if err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	logging.SetVerbose(opts.verbose)
+
 	//Init db
 	db, err := storage.NewSqlite()
 	if err != nil {
-		log.Fatalf("Failed to connect to db: %v", err)
+		return fmt.Errorf("failed to connect to db: %w", err)
+	}
+
+	identities, err := storage.DefaultIdentityFile()
+	if err != nil {
+		return err
+	}
+
+	repo := task.NewTaskRepository(db, identities)
+	if err := repo.EnsureSchema(ctx); err != nil {
+		return fmt.Errorf("failed to prepare db: %w", err)
 	}
 
-	repo := task.NewTaskRepository(db)
-	service := task.NewTaskService(repo)
+	out, err := presenter.New(os.Stdout, opts.output)
+	if err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	a := &app{
+		service: task.NewTaskService(repo),
+		out:     out,
+		output:  opts.output,
+	}
 
 	// 🔹 Check if user provided a command (Single Command Mode)
-	if len(os.Args) > 1 {
-		executeCommand(service, os.Args[1:])
-		return
+	if len(args) > 0 {
+		// Ctrl-C cancels the running command instead of killing the process
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		return executeCommand(ctx, a, args)
 	}
 
-	startInteractiveMode(service)
-}
\ No newline at end of file
+	startInteractiveMode(ctx, a)
+	return nil
+}
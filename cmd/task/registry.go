@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// handler runs a command with its positional arguments. Flag values are
+// captured by the closure returned from command.setup.
+type handler func(ctx context.Context, a *app, args []string) error
+
+// command describes a CLI command once for both single-shot and interactive mode
+type command struct {
+	name    string
+	aliases []string
+	// args is the synopsis of the positional arguments, e.g. "<task_id>"
+	args string
+	help string
+	// minArgs is the number of positional arguments that must be given
+	minArgs int
+	// interactive commands are only available at the interactive prompt
+	interactive bool
+	// hidden commands are left out of help and completion
+	hidden bool
+	// setup defines the command's flags on fs and returns its handler
+	setup func(fs *flag.FlagSet) handler
+	// argCompletion says what each positional argument is, for tab
+	// completion. The last entry also applies to any further arguments.
+	argCompletion []completion
+	// flagCompletion says what the values of flags are, by flag name
+	flagCompletion map[string]completion
+}
+
+// registry holds the known commands by name and alias
+type registry struct {
+	commands []*command
+	byName   map[string]*command
+}
+
+func newRegistry(commands ...*command) *registry {
+	r := &registry{byName: map[string]*command{}}
+	for _, cmd := range commands {
+		r.add(cmd)
+	}
+	return r
+}
+
+func (r *registry) add(cmd *command) {
+	r.commands = append(r.commands, cmd)
+	r.byName[cmd.name] = cmd
+	for _, alias := range cmd.aliases {
+		r.byName[alias] = cmd
+	}
+}
+
+// lookup returns the command with the given name or alias
+func (r *registry) lookup(name string, interactive bool) (*command, bool) {
+	cmd, ok := r.byName[name]
+	if !ok || (cmd.interactive && !interactive) {
+		return nil, false
+	}
+	return cmd, true
+}
+
+// dispatch parses args, whose first element is the command name, and runs
+// the command
+func (r *registry) dispatch(ctx context.Context, a *app, args []string) error {
+	cmd, ok := r.lookup(args[0], a.interactive)
+	if !ok {
+		return fmt.Errorf("unknown command: %s (run 'help' for a list of commands)", args[0])
+	}
+
+	fs := cmd.flagSet()
+	run := cmd.setup(fs)
+
+	positional, err := parseInterspersed(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		cmd.printUsage(os.Stdout)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v\n%s", cmd.name, err, cmd.synopsis())
+	}
+
+	if len(positional) < cmd.minArgs {
+		return fmt.Errorf("%s", cmd.synopsis())
+	}
+
+	return run(ctx, a, positional)
+}
+
+// flagSet returns an empty FlagSet for the command. Errors and usage are
+// reported by dispatch rather than the flag package.
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// synopsis returns the one-line usage of the command
+func (c *command) synopsis() string {
+	var b strings.Builder
+	b.WriteString("usage: " + c.name)
+
+	fs := c.flagSet()
+	c.setup(fs)
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		b.WriteString(" [flags]")
+	}
+	if c.args != "" {
+		b.WriteString(" " + c.args)
+	}
+	return b.String()
+}
+
+// printUsage writes the synopsis, help text, aliases and flags of the command
+func (c *command) printUsage(w io.Writer) {
+	fmt.Fprintln(w, c.synopsis())
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, c.help)
+	if len(c.aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+	}
+
+	fs := c.flagSet()
+	c.setup(fs)
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(w, "\nFlags:")
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+	}
+}
+
+// printHelp lists the commands available in the given mode
+func (r *registry) printHelp(w io.Writer, interactive bool) {
+	if !interactive {
+		fmt.Fprintln(w, "Usage: task [--output text|json|yaml|csv|table] [--verbose] <command> [flags] [args]")
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "Available commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range r.commands {
+		if cmd.hidden || (cmd.interactive && !interactive) {
+			continue
+		}
+		name := cmd.name
+		if cmd.args != "" {
+			name += " " + cmd.args
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", name, firstLine(cmd.help))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'help <command>' for the flags of a command.")
+	fmt.Fprintln(w, "Add --output json|yaml|csv|table to any command for machine-readable output.")
+}
+
+// parseInterspersed parses flags that may appear before, between or after
+// positional arguments, and returns the positional arguments. Everything
+// after "--" is positional.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		// The flag package consumes a "--" and stops parsing after it
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
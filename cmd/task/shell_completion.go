@@ -0,0 +1,278 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ryuux05/task-cli/presenter"
+)
+
+// completionShells are the shells `task completion` writes scripts for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// The scripts complete command names and flags from the command definitions
+// baked into them. Arguments and flag values, such as task IDs and member
+// names, are asked from `task __complete`, which prints one candidate per
+// line as "text<tab>hint".
+
+// completionFlag is a flag as it appears in a completion script
+type completionFlag struct {
+	name     string
+	usage    string
+	hasValue bool
+}
+
+// globalCompletionFlags are the flags extractGlobalFlags accepts
+var globalCompletionFlags = []completionFlag{
+	{name: "--output", usage: "Output format (" + strings.Join(presenter.OutputFormats, ", ") + ")", hasValue: true},
+	{name: "--verbose", usage: "Print diagnostic messages to stderr"},
+}
+
+// completionFlags returns the flags of cmd
+func (c *command) completionFlags() []completionFlag {
+	var flags []completionFlag
+	fs := c.flagSet()
+	c.setup(fs)
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, completionFlag{
+			name:     "-" + f.Name,
+			usage:    f.Usage,
+			hasValue: !isBoolFlag(f),
+		})
+	})
+	return flags
+}
+
+// completionCommands returns the commands that can be run from the shell
+func (r *registry) completionCommands() []*command {
+	var cmds []*command
+	for _, cmd := range r.commands {
+		if !cmd.hidden && !cmd.interactive {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// writeCompletionScript writes the completion script for shell
+func (r *registry) writeCompletionScript(w io.Writer, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = r.bashCompletion()
+	case "zsh":
+		script = r.zshCompletion()
+	case "fish":
+		script = r.fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell: %s (use %s)", shell, strings.Join(completionShells, ", "))
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+func (r *registry) bashCompletion() string {
+	var names, flagCases []string
+	for _, cmd := range r.completionCommands() {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+
+		var flags []string
+		for _, f := range cmd.completionFlags() {
+			flags = append(flags, f.name)
+		}
+		if len(flags) > 0 {
+			pattern := strings.Join(append([]string{cmd.name}, cmd.aliases...), "|")
+			flagCases = append(flagCases, fmt.Sprintf("            %s) flags+=\" %s\" ;;", pattern, strings.Join(flags, " ")))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(`# bash completion for task
+# Load it with: source <(task completion bash)
+
+_task() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    local prev=${COMP_WORDS[COMP_CWORD-1]}
+    local cmd="" i
+
+    # The command is the first word that is not a global flag
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case ${COMP_WORDS[i]} in
+            --output | -output) ((i++)) ;;
+            -*) ;;
+            *) cmd=${COMP_WORDS[i]}; break ;;
+        esac
+    done
+
+    if [[ -z $cmd && $cur != -* && $prev != --output && $prev != -output ]]; then
+`)
+	fmt.Fprintf(&b, "        COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellQuote(strings.Join(names, " ")))
+	b.WriteString(`        return
+    fi
+
+    if [[ $cur == -* ]]; then
+        local flags="--output --verbose"
+        case $cmd in
+`)
+	for _, c := range flagCases {
+		b.WriteString(c + "\n")
+	}
+	b.WriteString(`        esac
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+        return
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(task __complete -- "${COMP_WORDS[@]:1:COMP_CWORD-1}" "$cur" 2>/dev/null | cut -f1))
+}
+
+complete -F _task task
+`)
+	return b.String()
+}
+
+func (r *registry) zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef task
+# zsh completion for task
+# Load it with: source <(task completion zsh)
+# or save it as _task in a directory on $fpath
+
+_task() {
+    local -a candidates
+    local cmd i
+
+    # The command is the first word that is not a global flag
+    for ((i = 2; i < CURRENT; i++)); do
+        case ${words[i]} in
+            --output | -output) ((i++)) ;;
+            -*) ;;
+            *) cmd=${words[i]}; break ;;
+        esac
+    done
+
+    if [[ -z $cmd && $PREFIX != -* && ${words[CURRENT-1]} != (--output|-output) ]]; then
+        candidates=(
+`)
+	for _, cmd := range r.completionCommands() {
+		fmt.Fprintf(&b, "            %s\n", shellQuote(zshDescribe(cmd.name, firstLine(cmd.help))))
+	}
+	b.WriteString(`        )
+        _describe -t commands 'command' candidates
+        return
+    fi
+
+    if [[ $PREFIX == -* ]]; then
+        case $cmd in
+`)
+	for _, cmd := range r.completionCommands() {
+		flags := cmd.completionFlags()
+		if len(flags) == 0 {
+			continue
+		}
+		pattern := strings.Join(append([]string{cmd.name}, cmd.aliases...), "|")
+		fmt.Fprintf(&b, "            %s) candidates=(", pattern)
+		for i, f := range flags {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			b.WriteString(shellQuote(zshDescribe(f.name, f.usage)))
+		}
+		b.WriteString(") ;;\n")
+	}
+	b.WriteString("        esac\n        candidates+=(")
+	for i, f := range globalCompletionFlags {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(shellQuote(zshDescribe(f.name, f.usage)))
+	}
+	b.WriteString(`)
+        _describe -t flags 'flag' candidates
+        return
+    fi
+
+    local line text hint
+    for line in "${(@f)$(task __complete -- "${(@Q)words[2,CURRENT-1]}" "${(Q)PREFIX}" 2>/dev/null)}"; do
+        [[ -n $line ]] || continue
+        text=${line%%$'\t'*}
+        hint=${line#*$'\t'}
+        text=${text//:/\\:}
+        if [[ -n $hint ]]; then
+            candidates+=("$text:$hint")
+        else
+            candidates+=("$text")
+        fi
+    done
+    _describe -t values 'value' candidates
+}
+
+if [[ $zsh_eval_context[-1] == loadautofunc ]]; then
+    _task "$@"
+else
+    compdef _task task
+fi
+`)
+	return b.String()
+}
+
+func (r *registry) fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for task
+# Load it with: task completion fish | source
+# or save it as ~/.config/fish/completions/task.fish
+
+complete -c task -f
+`)
+	for _, f := range globalCompletionFlags {
+		fmt.Fprintf(&b, "complete -c task -l %s -d %s", strings.TrimLeft(f.name, "-"), fishQuote(f.usage))
+		if f.hasValue {
+			b.WriteString(" -x")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "complete -c task -l output -a %s\n", fishQuote(strings.Join(presenter.OutputFormats, " ")))
+
+	b.WriteString("\n# Commands\n")
+	for _, cmd := range r.completionCommands() {
+		fmt.Fprintf(&b, "complete -c task -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(firstLine(cmd.help)))
+	}
+
+	b.WriteString("\n# Command flags\n")
+	for _, cmd := range r.completionCommands() {
+		condition := fishQuote("__fish_seen_subcommand_from " + strings.Join(append([]string{cmd.name}, cmd.aliases...), " "))
+		for _, f := range cmd.completionFlags() {
+			fmt.Fprintf(&b, "complete -c task -n %s -o %s -d %s", condition, strings.TrimLeft(f.name, "-"), fishQuote(f.usage))
+			if f.hasValue {
+				b.WriteString(" -x")
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString(`
+# Arguments and flag values, such as task IDs and member names
+complete -c task -n 'not __fish_use_subcommand' -a '(task __complete -- (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`)
+	return b.String()
+}
+
+// shellQuote quotes s for bash and zsh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, where a backslash escapes ' and \ inside
+// single quotes
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// zshDescribe formats a name and description for _describe
+func zshDescribe(name, description string) string {
+	return strings.ReplaceAll(name, ":", `\:`) + ":" + description
+}
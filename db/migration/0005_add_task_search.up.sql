@@ -0,0 +1,40 @@
+ALTER TABLE tasks ADD COLUMN description TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS task_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    author TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(name, description, comments);
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+    INSERT INTO tasks_fts (rowid, name, description, comments) VALUES (new.id, new.name, new.description, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF name, description ON tasks BEGIN
+    UPDATE tasks_fts SET name = new.name, description = new.description WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM tasks_fts WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_comments_fts_insert AFTER INSERT ON task_comments BEGIN
+    UPDATE tasks_fts
+    SET comments = (SELECT IFNULL(group_concat(body, ' '), '') FROM task_comments WHERE task_id = new.task_id)
+    WHERE rowid = new.task_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS task_comments_fts_delete AFTER DELETE ON task_comments BEGIN
+    UPDATE tasks_fts
+    SET comments = (SELECT IFNULL(group_concat(body, ' '), '') FROM task_comments WHERE task_id = old.task_id)
+    WHERE rowid = old.task_id;
+END;
+
+INSERT INTO tasks_fts (rowid, name, description, comments)
+SELECT t.id, t.name, t.description, '' FROM tasks t;
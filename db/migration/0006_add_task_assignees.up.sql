@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS task_assignees (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    member TEXT NOT NULL,
+    role TEXT NOT NULL,
+    UNIQUE (task_id, member)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_assignees_member ON task_assignees(member);
+
+CREATE TRIGGER IF NOT EXISTS tasks_delete_assignees AFTER DELETE ON tasks BEGIN
+    DELETE FROM task_assignees WHERE task_id = old.id;
+END;
+
+INSERT OR IGNORE INTO task_assignees (task_id, member, role)
+SELECT id, owner, 'owner' FROM tasks ORDER BY id;
+
+INSERT OR IGNORE INTO task_assignees (task_id, member, role)
+SELECT id, collaborator, 'assignee' FROM tasks
+WHERE IFNULL(collaborator, '') != '' ORDER BY id;
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN completed_at;
+ALTER TABLE tasks DROP COLUMN updated_by;
+ALTER TABLE tasks DROP COLUMN updated_at;
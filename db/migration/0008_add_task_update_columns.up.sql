@@ -0,0 +1,6 @@
+-- 0003 adds the same columns but can't run on SQLite, which refuses to add
+-- a NOT NULL column without a default or one defaulting to CURRENT_TIMESTAMP.
+-- updated_at is NULL for tasks not changed since.
+ALTER TABLE tasks ADD COLUMN updated_at TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN updated_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN completed_at TIMESTAMP;
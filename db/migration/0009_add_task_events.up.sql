@@ -0,0 +1,32 @@
+INSERT OR IGNORE INTO status (name) VALUES ('in_progress');
+
+CREATE TABLE IF NOT EXISTS task_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id);
+CREATE INDEX IF NOT EXISTS idx_task_events_created_at ON task_events(created_at);
+
+CREATE TRIGGER IF NOT EXISTS tasks_delete_events AFTER DELETE ON tasks BEGIN
+    DELETE FROM task_events WHERE task_id = old.id;
+END;
+
+-- Tasks done before completed_at was recorded were most likely completed by
+-- their last change
+UPDATE tasks SET completed_at = COALESCE(updated_at, created_at)
+WHERE completed_at IS NULL AND status = (SELECT id FROM status WHERE name = 'done');
+
+UPDATE tasks SET completed_at = NULL
+WHERE status != (SELECT id FROM status WHERE name = 'done');
+
+INSERT INTO task_events (task_id, type, value, actor, created_at)
+SELECT id, 'created', '', owner, created_at FROM tasks ORDER BY id;
+
+INSERT INTO task_events (task_id, type, value, actor, created_at)
+SELECT id, 'status', 'done', IFNULL(updated_by, ''), completed_at FROM tasks
+WHERE completed_at IS NOT NULL ORDER BY completed_at;
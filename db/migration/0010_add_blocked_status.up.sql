@@ -0,0 +1 @@
+INSERT OR IGNORE INTO status (name) VALUES ('blocked');
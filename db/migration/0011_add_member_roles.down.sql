@@ -0,0 +1 @@
+ALTER TABLE members DROP COLUMN role;
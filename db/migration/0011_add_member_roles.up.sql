@@ -0,0 +1,9 @@
+ALTER TABLE members ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+
+-- A database needs an admin to manage members, so the current member, or
+-- else the earliest one, becomes admin
+UPDATE members SET role = 'admin'
+WHERE id = COALESCE(
+    (SELECT m.id FROM members m JOIN current_member c ON c.member_name = m.name),
+    (SELECT MIN(id) FROM members))
+AND NOT EXISTS (SELECT 1 FROM members WHERE role = 'admin');
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS member_sessions;
+
+ALTER TABLE members DROP COLUMN password_hash;
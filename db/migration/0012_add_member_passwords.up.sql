@@ -0,0 +1,9 @@
+ALTER TABLE members ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+
+-- Only a hash of each token is stored; the token itself is kept by the
+-- member's identity file
+CREATE TABLE IF NOT EXISTS member_sessions (
+    token_hash TEXT PRIMARY KEY,
+    member TEXT NOT NULL REFERENCES members(name),
+    expires_at TIMESTAMP NOT NULL
+);
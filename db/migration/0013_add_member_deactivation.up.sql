@@ -0,0 +1,3 @@
+-- Members who left keep their tasks and comments but drop out of assignment
+-- and tab completion
+ALTER TABLE members ADD COLUMN deactivated_at TIMESTAMP;
@@ -0,0 +1,24 @@
+// Package logging prints the diagnostic messages enabled by --verbose
+package logging
+
+import (
+	"fmt"
+	"os"
+)
+
+// verbose enables diagnostic messages on stderr
+var verbose bool
+
+// SetVerbose turns diagnostic messages on or off
+func SetVerbose(v bool) {
+	verbose = v
+}
+
+// Debugf prints a diagnostic message to stderr when verbose output is enabled.
+// Stdout is kept for command output so it can be piped and parsed.
+func Debugf(format string, args ...interface{}) {
+	if !verbose {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "DEBUG: "+format+"\n", args...)
+}
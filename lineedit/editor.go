@@ -0,0 +1,446 @@
+// Package lineedit reads lines from a terminal with editing, history,
+// reverse search and tab completion
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// Candidate is a completion for the word before the cursor. Hint is shown
+// next to it when several candidates are listed, e.g. the name of a task ID.
+type Candidate struct {
+	Text string
+	Hint string
+}
+
+// Completer returns the completions for line with the cursor at pos (in
+// runes), and the position where the completed word starts
+type Completer func(line string, pos int) (candidates []Candidate, start int)
+
+// Editor reads lines from stdin. When stdin is not a terminal, lines are read
+// without editing so input can be piped in.
+type Editor struct {
+	History   *History
+	Completer Completer
+
+	in      *os.File
+	out     io.Writer
+	scanner *bufio.Scanner
+	// reader buffers raw terminal input between lines
+	reader *bufio.Reader
+
+	// line being edited and the cursor position in it
+	line []rune
+	pos  int
+	// historyIndex is the history entry being shown, History.Len() when
+	// editing a new line. pending keeps the new line while browsing history.
+	historyIndex int
+	pending      []rune
+}
+
+func NewEditor(history *History, completer Completer) *Editor {
+	if history == nil {
+		history = &History{}
+	}
+	return &Editor{
+		History:   history,
+		Completer: completer,
+		in:        os.Stdin,
+		out:       os.Stdout,
+	}
+}
+
+// ReadLine prints prompt and returns the next line, without the newline.
+// It returns io.EOF at the end of input or on Ctrl-D on an empty line, and
+// ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	fd := int(e.in.Fd())
+	if !term.IsTerminal(fd) {
+		return e.readPlain(prompt)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return e.readPlain(prompt)
+	}
+	defer term.Restore(fd, state)
+
+	return e.readRaw(prompt)
+}
+
+// readPlain reads a line without editing
+func (e *Editor) readPlain(prompt string) (string, error) {
+	if e.scanner == nil {
+		e.scanner = bufio.NewScanner(e.in)
+	}
+	fmt.Fprint(e.out, prompt)
+	if !e.scanner.Scan() {
+		if err := e.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return e.scanner.Text(), nil
+}
+
+// Key codes returned by ReadKey. Other keys are returned as the rune typed.
+const (
+	KeyCtrlA     = 1
+	KeyCtrlB     = 2
+	KeyCtrlC     = 3
+	KeyCtrlD     = 4
+	KeyCtrlE     = 5
+	KeyCtrlF     = 6
+	KeyCtrlG     = 7
+	KeyCtrlH     = 8
+	KeyTab       = 9
+	KeyCtrlK     = 11
+	KeyCtrlL     = 12
+	KeyEnter     = 13
+	KeyCtrlN     = 14
+	KeyCtrlP     = 16
+	KeyCtrlR     = 18
+	KeyCtrlU     = 21
+	KeyCtrlW     = 23
+	KeyEscape    = 27
+	KeyBackspace = 127
+
+	// Keys sent as escape sequences are mapped past the Unicode range
+	KeyUp = unicode.MaxRune + 1 + iota
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyDelete
+	KeyUnknown
+)
+
+// readRaw reads a line in raw mode, handling editing keys
+func (e *Editor) readRaw(prompt string) (string, error) {
+	if e.reader == nil {
+		e.reader = bufio.NewReader(e.in)
+	}
+	r := e.reader
+	e.line = e.line[:0]
+	e.pos = 0
+	e.historyIndex = e.History.Len()
+	e.pending = nil
+	e.refresh(prompt)
+
+	for {
+		key, err := ReadKey(r)
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case KeyEnter, '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.line), nil
+		case KeyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case KeyCtrlD:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteRight()
+		case KeyBackspace, KeyCtrlH:
+			if e.pos > 0 {
+				e.line = append(e.line[:e.pos-1], e.line[e.pos:]...)
+				e.pos--
+			}
+		case KeyDelete:
+			e.deleteRight()
+		case KeyLeft, KeyCtrlB:
+			if e.pos > 0 {
+				e.pos--
+			}
+		case KeyRight, KeyCtrlF:
+			if e.pos < len(e.line) {
+				e.pos++
+			}
+		case KeyHome, KeyCtrlA:
+			e.pos = 0
+		case KeyEnd, KeyCtrlE:
+			e.pos = len(e.line)
+		case KeyUp, KeyCtrlP:
+			e.showHistory(e.historyIndex - 1)
+		case KeyDown, KeyCtrlN:
+			e.showHistory(e.historyIndex + 1)
+		case KeyCtrlK:
+			e.line = e.line[:e.pos]
+		case KeyCtrlU:
+			e.line = append(e.line[:0], e.line[e.pos:]...)
+			e.pos = 0
+		case KeyCtrlW:
+			start := e.pos
+			for start > 0 && unicode.IsSpace(e.line[start-1]) {
+				start--
+			}
+			for start > 0 && !unicode.IsSpace(e.line[start-1]) {
+				start--
+			}
+			e.line = append(e.line[:start], e.line[e.pos:]...)
+			e.pos = start
+		case KeyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case KeyTab:
+			e.complete()
+		case KeyCtrlR:
+			line, accepted, err := e.reverseSearch(r)
+			if err != nil {
+				return "", err
+			}
+			if accepted {
+				fmt.Fprint(e.out, "\r\n")
+				return line, nil
+			}
+		default:
+			if key < unicode.MaxRune && unicode.IsPrint(key) {
+				e.insert([]rune{key})
+			}
+		}
+		e.refresh(prompt)
+	}
+}
+
+func (e *Editor) insert(text []rune) {
+	line := make([]rune, 0, len(e.line)+len(text))
+	line = append(line, e.line[:e.pos]...)
+	line = append(line, text...)
+	line = append(line, e.line[e.pos:]...)
+	e.line = line
+	e.pos += len(text)
+}
+
+func (e *Editor) deleteRight() {
+	if e.pos < len(e.line) {
+		e.line = append(e.line[:e.pos], e.line[e.pos+1:]...)
+	}
+}
+
+// showHistory replaces the line with history entry i. i == History.Len()
+// returns to the line that was being typed.
+func (e *Editor) showHistory(i int) {
+	if i < 0 || i > e.History.Len() {
+		return
+	}
+	if e.historyIndex == e.History.Len() {
+		e.pending = append([]rune(nil), e.line...)
+	}
+	e.historyIndex = i
+	if i == e.History.Len() {
+		e.line = append([]rune(nil), e.pending...)
+	} else {
+		e.line = []rune(e.History.Entry(i))
+	}
+	e.pos = len(e.line)
+}
+
+// refresh redraws the prompt and line and places the cursor
+func (e *Editor) refresh(prompt string) {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(prompt)
+	b.WriteString(strings.ReplaceAll(string(e.line), "\n", "↵"))
+	b.WriteString("\x1b[K")
+	if back := len(e.line) - e.pos; back > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", back)
+	}
+	fmt.Fprint(e.out, b.String())
+}
+
+// complete completes the word before the cursor. A single candidate replaces
+// the word; otherwise the longest common prefix is inserted, and when that
+// doesn't change anything the candidates are listed.
+func (e *Editor) complete() {
+	if e.Completer == nil {
+		return
+	}
+	candidates, start := e.Completer(string(e.line), e.pos)
+	if len(candidates) == 0 || start < 0 || start > e.pos {
+		return
+	}
+
+	word := string(e.line[start:e.pos])
+	replacement := candidates[0].Text
+	if len(candidates) == 1 {
+		replacement += " "
+	} else {
+		for _, c := range candidates[1:] {
+			replacement = commonPrefix(replacement, c.Text)
+		}
+	}
+
+	if replacement != word && strings.HasPrefix(replacement, word) {
+		rest := append([]rune(replacement), e.line[e.pos:]...)
+		e.line = append(e.line[:start], rest...)
+		e.pos = start + utf8.RuneCountInString(replacement)
+		return
+	}
+
+	if len(candidates) > 1 {
+		e.listCandidates(candidates)
+	}
+}
+
+// listCandidates prints the candidates below the line, in columns unless they
+// have hints
+func (e *Editor) listCandidates(candidates []Candidate) {
+	width := 0
+	for _, c := range candidates {
+		if n := utf8.RuneCountInString(c.Text); n > width {
+			width = n
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\r\n")
+	for _, c := range candidates {
+		c.Hint = strings.Join(strings.Fields(c.Hint), " ")
+		if c.Hint != "" {
+			fmt.Fprintf(&b, "%-*s  %s\r\n", width, c.Text, c.Hint)
+		} else {
+			fmt.Fprintf(&b, "%s\r\n", c.Text)
+		}
+	}
+	fmt.Fprint(e.out, b.String())
+}
+
+// reverseSearch implements Ctrl-R: typed characters search backwards through
+// history, Ctrl-R again finds an older match. Enter runs the match, Ctrl-G
+// or Ctrl-C cancels, and any other key puts the match on the line for editing.
+func (e *Editor) reverseSearch(r *bufio.Reader) (line string, accepted bool, err error) {
+	var query []rune
+	original := append([]rune(nil), e.line...)
+	match := e.History.Len()
+	found := ""
+
+	search := func(from int) {
+		if from >= e.History.Len() {
+			from = e.History.Len() - 1
+		}
+		for i := from; i >= 0; i-- {
+			if strings.Contains(e.History.Entry(i), string(query)) {
+				match = i
+				found = e.History.Entry(i)
+				return
+			}
+		}
+	}
+
+	for {
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), strings.ReplaceAll(found, "\n", "↵"))
+
+		key, err := ReadKey(r)
+		if err != nil {
+			return "", false, err
+		}
+
+		switch key {
+		case KeyEnter, '\n':
+			return found, found != "", nil
+		case KeyCtrlG, KeyCtrlC:
+			e.line = original
+			e.pos = len(e.line)
+			return "", false, nil
+		case KeyCtrlR:
+			search(match - 1)
+		case KeyBackspace, KeyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				search(e.History.Len() - 1)
+			}
+		default:
+			if key < unicode.MaxRune && unicode.IsPrint(key) {
+				query = append(query, key)
+				search(match)
+				continue
+			}
+			if found != "" {
+				e.line = []rune(found)
+				e.pos = len(e.line)
+				e.historyIndex = match
+			}
+			return "", false, nil
+		}
+	}
+}
+
+// ReadKey reads one key press from a terminal in raw mode, decoding the
+// escape sequences of the arrow, home, end and delete keys
+func ReadKey(r *bufio.Reader) (rune, error) {
+	key, _, err := r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if key != KeyEscape {
+		return key, nil
+	}
+
+	// A lone escape has nothing buffered after it
+	if r.Buffered() == 0 {
+		return KeyEscape, nil
+	}
+	next, _, err := r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	if next != '[' && next != 'O' {
+		return KeyUnknown, nil
+	}
+
+	var seq []rune
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		seq = append(seq, c)
+		if c >= 0x40 && c <= 0x7e {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		return KeyUp, nil
+	case "B":
+		return KeyDown, nil
+	case "C":
+		return KeyRight, nil
+	case "D":
+		return KeyLeft, nil
+	case "H", "1~", "7~":
+		return KeyHome, nil
+	case "F", "4~", "8~":
+		return KeyEnd, nil
+	case "3~":
+		return KeyDelete, nil
+	}
+	return KeyUnknown, nil
+}
+
+func commonPrefix(a, b string) string {
+	ar, br := []rune(a), []rune(b)
+	n := 0
+	for n < len(ar) && n < len(br) && ar[n] == br[n] {
+		n++
+	}
+	return string(ar[:n])
+}
@@ -0,0 +1,131 @@
+package lineedit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is the number of entries kept in memory and in the history file
+const maxHistory = 1000
+
+// History is the list of previously entered lines, oldest first. When it has
+// a path, new entries are appended to that file.
+type History struct {
+	entries []string
+	path    string
+}
+
+// LoadHistory reads the history file at path. A missing file is not an error;
+// it is created when the first entry is added.
+func LoadHistory(path string) (*History, error) {
+	h := &History{path: path}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return h, fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := unescapeEntry(scanner.Text()); line != "" {
+			h.entries = append(h.entries, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return h, fmt.Errorf("failed to read history file: %v", err)
+	}
+
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		// Keep the file from growing without bound
+		if err := h.rewrite(); err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
+
+// Add appends a line to the history and the history file. Blank lines and
+// repeats of the previous entry are skipped.
+func (h *History) Add(line string) error {
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[1:]
+	}
+
+	if h.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %v", err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, escapeEntry(line)); err != nil {
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	return nil
+}
+
+// Len returns the number of entries
+func (h *History) Len() int {
+	return len(h.entries)
+}
+
+// Entry returns the i-th entry, oldest first
+func (h *History) Entry(i int) string {
+	return h.entries[i]
+}
+
+// rewrite replaces the history file with the current entries
+func (h *History) rewrite() error {
+	var b strings.Builder
+	for _, entry := range h.entries {
+		b.WriteString(escapeEntry(entry) + "\n")
+	}
+	if err := os.WriteFile(h.path, []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	return nil
+}
+
+// escapeEntry stores multi-line entries on one line of the history file
+func escapeEntry(line string) string {
+	line = strings.ReplaceAll(line, `\`, `\\`)
+	return strings.ReplaceAll(line, "\n", `\n`)
+}
+
+func unescapeEntry(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,616 @@
+package presenter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ryuux05/task-cli/task"
+	"golang.org/x/term"
+)
+
+// DataPresenter renders results in a machine-readable format, see RenderOutput
+type DataPresenter struct {
+	w      io.Writer
+	format string
+}
+
+func NewDataPresenter(w io.Writer, format string) Presenter {
+	return &DataPresenter{w: w, format: format}
+}
+
+func (p *DataPresenter) Task(t *task.Task, message string) error {
+	return RenderOutput(p.w, p.format, t)
+}
+
+func (p *DataPresenter) Tasks(tasks []task.Task, columns []string) error {
+	var fields []string
+	for _, column := range columns {
+		fields = append(fields, columnField(column))
+	}
+	return RenderOutput(p.w, p.format, tasks, fields...)
+}
+
+func (p *DataPresenter) TaskDetail(detail *task.TaskDetail) error {
+	return RenderOutput(p.w, p.format, detail)
+}
+
+// SearchResults renders the results with matches marked by "**"
+func (p *DataPresenter) SearchResults(results []task.SearchResult) error {
+	render := strings.NewReplacer(task.HighlightStart, "**", task.HighlightEnd, "**")
+	for i := range results {
+		results[i].Name = render.Replace(results[i].Name)
+		results[i].Snippet = render.Replace(results[i].Snippet)
+	}
+	return RenderOutput(p.w, p.format, results)
+}
+
+func (p *DataPresenter) Members(members []task.Member, current string) error {
+	return RenderOutput(p.w, p.format, members)
+}
+
+func (p *DataPresenter) OrphanedMembers(orphans []task.OrphanedMember) error {
+	return RenderOutput(p.w, p.format, orphans)
+}
+
+// workItem is a row of MyWork in csv and table output
+type workItem struct {
+	Section string `json:"section"`
+	task.Task
+}
+
+// MyWork renders the sections as they are in json and yaml, and as one list
+// of tasks with a section column in csv and table output
+func (p *DataPresenter) MyWork(work *task.MyWork) error {
+	if p.format != OutputCSV && p.format != OutputTable {
+		return RenderOutput(p.w, p.format, work)
+	}
+
+	var items []workItem
+	sections := []struct {
+		name  string
+		tasks []task.Task
+	}{
+		{"overdue", work.Overdue},
+		{"blocked", work.Blocked},
+		{"owned", work.Owned},
+		{"collaborating", work.Collaborating},
+		{"completed_this_week", work.CompletedThisWeek},
+	}
+	for _, section := range sections {
+		for _, t := range section.tasks {
+			items = append(items, workItem{Section: section.name, Task: t})
+		}
+	}
+	return RenderOutput(p.w, p.format, items)
+}
+
+func (p *DataPresenter) Workload(workload []task.MemberWorkload) error {
+	return RenderOutput(p.w, p.format, workload)
+}
+
+// Stats renders the whole stats in json and yaml, and the series of periods
+// in csv and table output
+func (p *DataPresenter) Stats(stats *task.Stats) error {
+	if p.format == OutputCSV || p.format == OutputTable {
+		return RenderOutput(p.w, p.format, stats.Series)
+	}
+	return RenderOutput(p.w, p.format, stats)
+}
+
+// summaryItem is a row of a standup or digest in csv and table output
+type summaryItem struct {
+	Section string `json:"section"`
+	task.TaskEvent
+}
+
+// summaryItems lists the events of the sections of a standup or digest
+func summaryItems(sections map[string][]task.TaskEvent, order ...string) []summaryItem {
+	var items []summaryItem
+	for _, section := range order {
+		for _, e := range sections[section] {
+			items = append(items, summaryItem{Section: section, TaskEvent: e})
+		}
+	}
+	return items
+}
+
+// Standup renders the sections as they are in json and yaml, and as one list
+// of events with a section column in csv and table output
+func (p *DataPresenter) Standup(standup *task.Standup, templatePath string) error {
+	if p.format != OutputCSV && p.format != OutputTable {
+		return RenderOutput(p.w, p.format, standup)
+	}
+	return RenderOutput(p.w, p.format, summaryItems(map[string][]task.TaskEvent{
+		"completed":   standup.Completed,
+		"in_progress": standup.InProgress,
+		"blocked":     standup.Blocked,
+	}, "completed", "in_progress", "blocked"))
+}
+
+// Digest renders the digest as it is in json and yaml, and the events of its
+// sections with a section column in csv and table output
+func (p *DataPresenter) Digest(digest *task.Digest, templatePath string) error {
+	if p.format != OutputCSV && p.format != OutputTable {
+		return RenderOutput(p.w, p.format, digest)
+	}
+	return RenderOutput(p.w, p.format, summaryItems(map[string][]task.TaskEvent{
+		"completed": digest.Completed,
+		"started":   digest.Started,
+		"created":   digest.Created,
+		"blocked":   digest.Blocked,
+	}, "completed", "started", "created", "blocked"))
+}
+
+// Message is a no-op so that stdout only ever contains parseable data
+func (p *DataPresenter) Message(format string, args ...interface{}) error {
+	return nil
+}
+
+// columnField returns the output field name of a list column
+func columnField(column string) string {
+	switch column {
+	case "created":
+		return "created_at"
+	case "due":
+		return "due_date"
+	}
+	return column
+}
+
+// RenderOutput writes v, a struct or a slice of structs, in the given
+// machine-readable format. Field names and order come from the structs' json
+// tags, so every format has the same stable field order. When fields is not
+// empty, the csv and table formats only include those fields.
+func RenderOutput(w io.Writer, format string, v interface{}, fields ...string) error {
+	// Render nil slices as empty lists rather than null
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		return writeYAML(w, reflect.ValueOf(v), 0)
+	case OutputCSV:
+		header, rows := flattenRows(reflect.ValueOf(v), fields)
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return err
+		}
+		return cw.Error()
+	case OutputTable:
+		header, rows := flattenRows(reflect.ValueOf(v), fields)
+		return writeTable(w, header, rows, terminalWidth())
+	}
+	return fmt.Errorf("unsupported output format %q", format)
+}
+
+// jsonFieldName returns the json name of a struct field and whether it is
+// omitted when empty. ok is false for fields excluded from json.
+func jsonFieldName(f reflect.StructField) (name string, omitEmpty bool, ok bool) {
+	if f.PkgPath != "" && !f.Anonymous {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, true
+}
+
+// flattenRows turns a struct or slice of structs into a header and rows of
+// strings. Embedded structs are inlined and nested structs are prefixed with
+// their field name, e.g. task.id.
+func flattenRows(v reflect.Value, fields []string) ([]string, [][]string) {
+	v = indirect(v)
+
+	var items []reflect.Value
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, indirect(v.Index(i)))
+		}
+	} else {
+		items = append(items, v)
+	}
+
+	// The header comes from the element type so empty lists still get one
+	var elemType reflect.Type
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		elemType = v.Type().Elem()
+	} else {
+		elemType = v.Type()
+	}
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	header := flattenHeader(elemType, "")
+	var rows [][]string
+	for _, item := range items {
+		rows = append(rows, flattenValues(item))
+	}
+
+	if len(fields) == 0 {
+		return header, rows
+	}
+
+	// Keep only the requested fields, in the requested order
+	var index []int
+	var selected []string
+	for _, field := range fields {
+		for i, name := range header {
+			if name == field {
+				index = append(index, i)
+				selected = append(selected, name)
+			}
+		}
+	}
+	for r, row := range rows {
+		picked := make([]string, len(index))
+		for i, idx := range index {
+			picked[i] = row[idx]
+		}
+		rows[r] = picked
+	}
+	return selected, rows
+}
+
+// flattenHeader returns the flattened field names of a struct type
+func flattenHeader(t reflect.Type, prefix string) []string {
+	if t.Kind() != reflect.Struct {
+		return []string{strings.TrimSuffix(prefix, ".")}
+	}
+
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, ok := jsonFieldName(f)
+		if !ok {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case f.Anonymous && ft.Kind() == reflect.Struct:
+			names = append(names, flattenHeader(ft, prefix)...)
+		case ft.Kind() == reflect.Struct:
+			names = append(names, flattenHeader(ft, prefix+name+".")...)
+		default:
+			names = append(names, prefix+name)
+		}
+	}
+	return names
+}
+
+// flattenValues returns the flattened field values of a struct, matching flattenHeader
+func flattenValues(v reflect.Value) []string {
+	v = indirect(v)
+	if v.Kind() != reflect.Struct {
+		return []string{formatScalar(v)}
+	}
+
+	var values []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, _, ok := jsonFieldName(f); !ok {
+			continue
+		}
+		fv := v.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				values = append(values, make([]string, len(flattenHeader(ft, "")))...)
+				continue
+			}
+			values = append(values, flattenValues(fv)...)
+			continue
+		}
+		values = append(values, formatScalar(fv))
+	}
+	return values
+}
+
+// formatScalar formats a value for a single csv or table cell. Lists are
+// joined with "; ", using each element's String method when it has one.
+func formatScalar(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem := indirect(v.Index(i))
+			if elem.Kind() == reflect.Struct {
+				if _, ok := elem.Interface().(fmt.Stringer); !ok {
+					data, _ := json.Marshal(elem.Interface())
+					parts[i] = string(data)
+					continue
+				}
+			}
+			parts[i] = formatScalar(elem)
+		}
+		return strings.Join(parts, "; ")
+	}
+
+	data, _ := json.Marshal(v.Interface())
+	return string(data)
+}
+
+// writeYAML writes v as a YAML document using json tag names
+func writeYAML(w io.Writer, v reflect.Value, indent int) error {
+	v = indirect(v)
+	pad := strings.Repeat("  ", indent)
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			_, err := fmt.Fprintf(w, "%s[]\n", pad)
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := indirect(v.Index(i))
+			if elem.Kind() == reflect.Struct {
+				// The first field goes on the dash line, the rest are indented under it
+				var b strings.Builder
+				if err := writeYAML(&b, elem, indent+1); err != nil {
+					return err
+				}
+				text := strings.TrimPrefix(b.String(), pad+"  ")
+				if _, err := fmt.Fprintf(w, "%s- %s", pad, text); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s- %s\n", pad, yamlScalar(elem)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, omitEmpty, ok := jsonFieldName(f)
+			if !ok {
+				continue
+			}
+			fv := indirect(v.Field(i))
+			if f.Anonymous && fv.Kind() == reflect.Struct {
+				if err := writeYAML(w, fv, indent); err != nil {
+					return err
+				}
+				continue
+			}
+			if omitEmpty && (!fv.IsValid() || fv.IsZero()) {
+				continue
+			}
+			switch {
+			case fv.Kind() == reflect.Struct && fv.Type().NumField() > 0:
+				if _, err := fmt.Fprintf(w, "%s%s:\n", pad, name); err != nil {
+					return err
+				}
+				if err := writeYAML(w, fv, indent+1); err != nil {
+					return err
+				}
+			case (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Len() > 0:
+				if _, err := fmt.Fprintf(w, "%s%s:\n", pad, name); err != nil {
+					return err
+				}
+				if err := writeYAML(w, fv, indent+1); err != nil {
+					return err
+				}
+			case fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array:
+				if _, err := fmt.Fprintf(w, "%s%s: []\n", pad, name); err != nil {
+					return err
+				}
+			default:
+				if _, err := fmt.Fprintf(w, "%s%s: %s\n", pad, name, yamlScalar(fv)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	_, err := fmt.Fprintf(w, "%s%s\n", pad, yamlScalar(v))
+	return err
+}
+
+// yamlScalar formats a scalar value for YAML. Strings are quoted unless they
+// are plain words that YAML can't mistake for another type.
+func yamlScalar(v reflect.Value) string {
+	if !v.IsValid() {
+		return "null"
+	}
+	if v.Kind() != reflect.String {
+		return formatScalar(v)
+	}
+
+	s := v.String()
+	if yamlPlain(s) {
+		return s
+	}
+	// JSON strings are valid YAML double-quoted scalars
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// yamlPlain reports whether s can be written without quotes
+func yamlPlain(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	// Dates such as 2026-10-20 would be read back as timestamps
+	if s[0] >= '0' && s[0] <= '9' && strings.ContainsAny(s, "-:") {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == ' ' || r == '_' || r == '.' || r == '/' || r == '@' || r == '(' || r == ')':
+		case r == '-' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// writeTable writes rows as aligned columns. When maxWidth is positive the
+// widest columns are truncated until each line fits.
+func writeTable(w io.Writer, header []string, rows [][]string, maxWidth int) error {
+	const gap = 2
+
+	upper := make([]string, len(header))
+	for i, h := range header {
+		upper[i] = strings.ToUpper(h)
+	}
+
+	widths := make([]int, len(header))
+	for i, h := range upper {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			cell = strings.ReplaceAll(cell, "\n", " ")
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	if maxWidth > 0 {
+		shrinkColumns(widths, maxWidth-gap*(len(widths)-1))
+	}
+
+	writeRow := func(cells []string) error {
+		var b strings.Builder
+		for i, cell := range cells {
+			cell = truncate(strings.ReplaceAll(cell, "\n", " "), widths[i])
+			b.WriteString(cell)
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+gap))
+			}
+		}
+		b.WriteString("\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	if err := writeRow(upper); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shrinkColumns narrows the widest columns one character at a time until the
+// total fits in available. Columns never shrink below minColumnWidth.
+func shrinkColumns(widths []int, available int) {
+	const minColumnWidth = 4
+	for {
+		total := 0
+		widest := -1
+		for i, width := range widths {
+			total += width
+			if width > minColumnWidth && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if total <= available || widest < 0 {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+// truncate shortens s to width runes, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when stdout
+// is not a terminal and COLUMNS is not set
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 0
+}
+
+// indirect dereferences pointers and interfaces
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+package presenter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+type testOwner struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type testItem struct {
+	ID     int        `json:"id"`
+	Name   string     `json:"name"`
+	Tags   []string   `json:"tags"`
+	Note   string     `json:"note,omitempty"`
+	Owner  *testOwner `json:"owner,omitempty"`
+	Secret string     `json:"-"`
+	hidden string
+}
+
+func TestRenderOutput(t *testing.T) {
+	item := testItem{ID: 1, Name: "Deploy", Tags: []string{"ops", "urgent"}, Owner: &testOwner{Name: "alice", Role: "admin"}, Secret: "x", hidden: "y"}
+	bare := testItem{ID: 2, Name: "Fix, then ship \"it\""}
+
+	tests := []struct {
+		name   string
+		format string
+		v      interface{}
+		fields []string
+		want   string
+	}{
+		{
+			name:   "yaml struct",
+			format: OutputYAML,
+			v:      item,
+			want:   "id: 1\nname: Deploy\ntags:\n  - ops\n  - urgent\nowner:\n  name: alice\n  role: admin\n",
+		},
+		{
+			name:   "yaml list",
+			format: OutputYAML,
+			v:      []testItem{item, bare},
+			want: "- id: 1\n  name: Deploy\n  tags:\n    - ops\n    - urgent\n  owner:\n    name: alice\n    role: admin\n" +
+				"- id: 2\n  name: \"Fix, then ship \\\"it\\\"\"\n  tags: []\n",
+		},
+		{name: "yaml empty list", format: OutputYAML, v: []testItem{}, want: "[]\n"},
+		{name: "yaml nil list", format: OutputYAML, v: []testItem(nil), want: "[]\n"},
+		{
+			name:   "yaml quotes strings that read as other types",
+			format: OutputYAML,
+			v:      []string{"yes", "Null", "42", "1e3", "2026-10-20", "10:30", " padded", "a: b", "#tag", "", "plain words", "-flag", "x-y"},
+			want:   "- \"yes\"\n- \"Null\"\n- \"42\"\n- \"1e3\"\n- \"2026-10-20\"\n- \"10:30\"\n- \" padded\"\n- \"a: b\"\n- \"#tag\"\n- \"\"\n- plain words\n- \"-flag\"\n- x-y\n",
+		},
+		{
+			name:   "yaml task",
+			format: OutputYAML,
+			v:      task.Member{Id: 3, Name: "bob", Role: task.MemberRoleViewer, CreatedAt: "2026-10-01T09:00:00Z"},
+			want:   "id: 3\nname: bob\nrole: viewer\ncreated_at: \"2026-10-01T09:00:00Z\"\n",
+		},
+		{
+			name:   "csv",
+			format: OutputCSV,
+			v:      []testItem{item, bare},
+			want: "id,name,tags,note,owner.name,owner.role\n" +
+				"1,Deploy,ops; urgent,,alice,admin\n" +
+				"2,\"Fix, then ship \"\"it\"\"\",,,,\n",
+		},
+		{name: "csv struct", format: OutputCSV, v: bare, want: "id,name,tags,note,owner.name,owner.role\n2,\"Fix, then ship \"\"it\"\"\",,,,\n"},
+		{name: "csv empty list has a header", format: OutputCSV, v: []testItem(nil), want: "id,name,tags,note,owner.name,owner.role\n"},
+		{
+			name:   "csv selected fields in order",
+			format: OutputCSV,
+			v:      []testItem{item},
+			fields: []string{"owner.name", "id", "unknown"},
+			want:   "owner.name,id\nalice,1\n",
+		},
+		{
+			name:   "csv task with assignees",
+			format: OutputCSV,
+			v: []task.Task{{
+				Id:        5,
+				Name:      "Review",
+				Status:    task.StatusPending,
+				Owner:     "alice",
+				Assignees: []task.Assignee{{Member: "alice", Role: task.RoleOwner}, {Member: "bob", Role: task.RoleReviewer}},
+			}},
+			fields: []string{"id", "name", "assignees"},
+			want:   "id,name,assignees\n5,Review,alice (owner); bob (reviewer)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderOutput(&buf, tt.format, tt.v, tt.fields...); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("RenderOutput(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderOutputUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderOutput(&buf, "xml", testItem{}); err == nil {
+		t.Errorf("RenderOutput(xml) = %q, want an error", buf.String())
+	}
+}
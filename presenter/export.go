@@ -0,0 +1,155 @@
+package presenter
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+// ExportSite writes a static site of the board to dir: index.html lists all
+// tasks, task-<id>.html shows each task and member-<name>.html lists the
+// tasks each member is assigned to. The assets are copied next to the pages
+// and all links are relative, so the directory can be put on a file share or
+// any web server as it is.
+func ExportSite(dir string, tasks []task.TaskDetail, members []task.Member) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating directory: %v", err)
+	}
+
+	list := make([]task.Task, len(tasks))
+	for i, d := range tasks {
+		list[i] = d.Task
+	}
+
+	// Give every member a page, with a file name that is safe on any system
+	pages := make(map[string]string, len(members))
+	used := make(map[string]bool)
+	var links []Link
+	for _, m := range members {
+		name := "member-" + slug(m.Name)
+		for n := 2; used[name]; n++ {
+			name = "member-" + slug(m.Name) + "-" + strconv.Itoa(n)
+		}
+		used[name] = true
+		pages[m.Name] = name + ".html"
+		links = append(links, Link{Text: m.Name, URL: pages[m.Name]})
+	}
+
+	taskURL := func(t task.Task) string {
+		return fmt.Sprintf("task-%d.html", t.Id)
+	}
+
+	err := writePage(filepath.Join(dir, "index.html"), func(w io.Writer) error {
+		return RenderTaskList(w, list, PageOptions{TaskURL: taskURL, Links: links})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		var assigned []task.Task
+		for _, t := range list {
+			if containsString(t.Members(), m.Name) {
+				assigned = append(assigned, t)
+			}
+		}
+		opts := PageOptions{
+			Title:    "Tasks for " + m.Name,
+			IndexURL: "index.html",
+			TaskURL:  taskURL,
+			Links:    links,
+		}
+		err := writePage(filepath.Join(dir, pages[m.Name]), func(w io.Writer) error {
+			return RenderTaskList(w, assigned, opts)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range tasks {
+		err := writePage(filepath.Join(dir, taskURL(d.Task)), func(w io.Writer) error {
+			return RenderTask(w, d, PageOptions{IndexURL: "index.html"})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return copyAssets(filepath.Join(dir, "assets"))
+}
+
+// writePage creates the file at path with what render writes
+func writePage(path string, render func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating HTML file: %v", err)
+	}
+	if err := render(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing HTML file: %v", err)
+	}
+	return nil
+}
+
+// copyAssets copies the assets, including theme overrides, to dir
+func copyAssets(dir string) error {
+	assets := Assets()
+	return fs.WalkDir(assets, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := fs.ReadFile(assets, path)
+		if err != nil {
+			return fmt.Errorf("error reading asset %s: %v", path, err)
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("error writing asset %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// slug turns a name into lowercase letters, digits and dashes
+func slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "member"
+	}
+	return b.String()
+}
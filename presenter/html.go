@@ -0,0 +1,399 @@
+package presenter
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ryuux05/task-cli/internal/logging"
+	"github.com/ryuux05/task-cli/task"
+)
+
+// TaskViewModel enhances Task data for template rendering
+type TaskViewModel struct {
+	Id          int
+	Name        string
+	StatusText  string
+	StatusClass string
+	CreatedAt   string
+	Owner       string
+	// Assignees are the members assigned to the task other than the owner
+	Assignees   []task.Assignee
+	Priority    string
+	Description string
+	// URL links to the page of the task, when tasks have their own pages
+	URL string
+}
+
+// TaskPageViewModel is the data of the page of one task
+type TaskPageViewModel struct {
+	TaskViewModel
+	Comments []task.Comment
+	// IndexURL links back to the task list, when there is one
+	IndexURL string
+	// InlineCSS is the shared stylesheet, put in the page so it stands alone
+	InlineCSS template.CSS
+}
+
+// TasksListViewModel represents a list of tasks for the template
+type TasksListViewModel struct {
+	Title      string
+	Tasks      []TaskViewModel
+	TotalTasks int
+	// Links are shown above the list, e.g. to the pages of members
+	Links []Link
+	// IndexURL links back to the list of all tasks, when this isn't it
+	IndexURL string
+	// Live is set when the page is served by `task serve` and can edit tasks
+	Live bool
+	// InlineCSS and InlineJS are the assets when they are put in the page
+	InlineCSS template.CSS
+	InlineJS  template.JS
+}
+
+// FromTask converts a regular Task to a view model
+func FromTask(t task.Task) TaskViewModel {
+	statusClass := "badge-warning"
+	switch t.Status {
+	case task.StatusDone:
+		statusClass = "badge-success"
+	case task.StatusInProgress:
+		statusClass = "badge-info"
+	case task.StatusBlocked:
+		statusClass = "badge-danger"
+	}
+
+	return TaskViewModel{
+		Id:          t.Id,
+		Name:        t.Name,
+		StatusText:  statusText(t.Status),
+		StatusClass: statusClass,
+		CreatedAt:   t.CreatedAt,
+		Owner:       t.Owner,
+		Assignees:   assignees(t),
+		Priority:    task.PriorityName(t.Priority),
+		Description: t.Description,
+	}
+}
+
+// assignees returns the assignees of a task other than its owner
+func assignees(t task.Task) []task.Assignee {
+	var list []task.Assignee
+	for _, a := range t.Assignees {
+		if a.Role != task.RoleOwner {
+			list = append(list, a)
+		}
+	}
+	return list
+}
+
+// Link is a link shown on a page
+type Link struct {
+	Text string
+	URL  string
+}
+
+// ViewOptions say where an HTML view is written and whether it is opened
+type ViewOptions struct {
+	// Out is the file to write. By default a task is written to a temporary
+	// file and the task list to task_cli_tasks_list.html in the home
+	// directory.
+	Out string
+	// Open opens the file in the default browser, when there is one
+	Open bool
+}
+
+// GenerateAndDisplayHTML creates an HTML view for a task and opens it in a browser
+func GenerateAndDisplayHTML(w io.Writer, d task.TaskDetail, opts ViewOptions) error {
+	file, err := createViewFile(opts.Out, "task-*.html")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := RenderTask(file, d, PageOptions{}); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing HTML file: %v", err)
+	}
+	return showFile(w, file.Name(), opts.Open)
+}
+
+// GenerateAndDisplayTaskList creates an HTML view for all tasks and opens it in a browser
+func GenerateAndDisplayTaskList(w io.Writer, tasks []task.Task, opts ViewOptions) error {
+	// Keep the list in the home directory by default for better visibility
+	var path string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		path = filepath.Join(homeDir, "task_cli_tasks_list.html")
+	}
+	if opts.Out != "" {
+		path = opts.Out
+	}
+
+	file, err := createViewFile(path, "tasks-list-*.html")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Inline the assets so the file can be opened or moved on its own
+	if err := RenderTaskList(file, tasks, PageOptions{InlineAssets: true}); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing HTML file: %v", err)
+	}
+	return showFile(w, file.Name(), opts.Open)
+}
+
+// WorkloadViewModel is the data of the workload report page
+type WorkloadViewModel struct {
+	GeneratedAt string
+	// Statuses are the status columns, in the order of each row's ByStatus
+	Statuses []string
+	Rows     []task.MemberWorkload
+	// InlineCSS is the shared stylesheet, put in the page so it stands alone
+	InlineCSS template.CSS
+}
+
+// GenerateAndDisplayWorkload creates an HTML view of the workload report and
+// opens it in a browser
+func GenerateAndDisplayWorkload(w io.Writer, workload []task.MemberWorkload, opts ViewOptions) error {
+	file, err := createViewFile(opts.Out, "workload-*.html")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := RenderWorkload(file, workload); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing HTML file: %v", err)
+	}
+	return showFile(w, file.Name(), opts.Open)
+}
+
+// RenderWorkload writes the HTML page of the workload report
+func RenderWorkload(w io.Writer, workload []task.MemberWorkload) error {
+	tmpl, err := parseTemplate("workload.html")
+	if err != nil {
+		return err
+	}
+
+	css, err := readAsset("css/base.css")
+	if err != nil {
+		return err
+	}
+
+	viewModel := WorkloadViewModel{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04"),
+		Rows:        workload,
+		InlineCSS:   template.CSS(css),
+	}
+	if len(workload) > 0 {
+		for _, c := range workload[0].ByStatus {
+			viewModel.Statuses = append(viewModel.Statuses, c.Status)
+		}
+	}
+	if err := tmpl.Execute(w, viewModel); err != nil {
+		return fmt.Errorf("error rendering template: %v", err)
+	}
+	return nil
+}
+
+// createViewFile creates the file at path, or a temporary file named after
+// pattern when path is empty
+func createViewFile(path, pattern string) (*os.File, error) {
+	var file *os.File
+	var err error
+	if path != "" {
+		file, err = os.Create(path)
+	} else {
+		file, err = os.CreateTemp("", pattern)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTML file: %v", err)
+	}
+	return file, nil
+}
+
+// RenderTask writes the HTML page of a task with its comments
+func RenderTask(w io.Writer, d task.TaskDetail, opts PageOptions) error {
+	tmpl, err := parseTemplate("task_view.html")
+	if err != nil {
+		return err
+	}
+
+	css, err := readAsset("css/base.css")
+	if err != nil {
+		return err
+	}
+
+	viewModel := TaskPageViewModel{
+		TaskViewModel: FromTask(d.Task),
+		Comments:      d.Comments,
+		IndexURL:      opts.IndexURL,
+		InlineCSS:     template.CSS(css),
+	}
+	if err := tmpl.Execute(w, viewModel); err != nil {
+		return fmt.Errorf("error rendering template: %v", err)
+	}
+	return nil
+}
+
+// PageOptions say how an HTML page is going to be used
+type PageOptions struct {
+	// Live pages are served by `task serve` and change tasks through its
+	// API; other pages are read-only
+	Live bool
+	// InlineAssets puts the CSS and JavaScript in the page, so it is a single
+	// self-contained file. Otherwise they are linked relative to the page,
+	// under assets/.
+	InlineAssets bool
+	// Title is the heading of a task list, "All Tasks" by default
+	Title string
+	// IndexURL links the page back to the list of all tasks
+	IndexURL string
+	// TaskURL returns the link to the page of a task, when tasks have pages
+	TaskURL func(t task.Task) string
+	// Links are shown above a task list
+	Links []Link
+}
+
+// RenderTaskList writes the HTML page listing tasks
+func RenderTaskList(w io.Writer, tasks []task.Task, opts PageOptions) error {
+	tmpl, err := parseTemplate("tasks_list.html")
+	if err != nil {
+		return err
+	}
+
+	// Convert tasks to view models
+	var taskViewModels []TaskViewModel
+	for _, t := range tasks {
+		viewModel := FromTask(t)
+		if opts.TaskURL != nil {
+			viewModel.URL = opts.TaskURL(t)
+		}
+		taskViewModels = append(taskViewModels, viewModel)
+	}
+
+	// Create the view model for the template
+	viewModel := TasksListViewModel{
+		Title:      opts.Title,
+		Tasks:      taskViewModels,
+		TotalTasks: len(taskViewModels),
+		Links:      opts.Links,
+		IndexURL:   opts.IndexURL,
+		Live:       opts.Live,
+	}
+	if viewModel.Title == "" {
+		viewModel.Title = "All Tasks"
+	}
+
+	if opts.InlineAssets {
+		base, err := readAsset("css/base.css")
+		if err != nil {
+			return err
+		}
+		css, err := readAsset("css/tasks_list.css")
+		if err != nil {
+			return err
+		}
+		js, err := readAsset("js/tasks_list.js")
+		if err != nil {
+			return err
+		}
+		viewModel.InlineCSS = template.CSS(base + "\n" + css)
+		viewModel.InlineJS = template.JS(js)
+	}
+
+	if err := tmpl.Execute(w, viewModel); err != nil {
+		return fmt.Errorf("error rendering template: %v", err)
+	}
+	return nil
+}
+
+// readAsset returns an asset from the theme directory or the embedded assets
+func readAsset(path string) (string, error) {
+	data, err := fs.ReadFile(Assets(), path)
+	if err != nil {
+		return "", fmt.Errorf("error reading asset %s: %v", path, err)
+	}
+	return string(data), nil
+}
+
+// parseTemplate parses a template from the theme directory or the embedded
+// templates
+func parseTemplate(templateName string) (*template.Template, error) {
+	tmpl, err := template.ParseFS(Files(), "templates/"+templateName)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	return tmpl, nil
+}
+
+// showFile prints where the view was written and opens it in the default
+// browser when open is set. Not having a browser, e.g. over SSH or on CI, is
+// not an error: the file can still be opened by hand.
+func showFile(w io.Writer, path string, open bool) error {
+	fmt.Fprintf(w, "HTML file generated at: %s\n", path)
+	if !open {
+		return nil
+	}
+
+	if reason := headless(); reason != "" {
+		logging.Debugf("Not opening a browser: %s", reason)
+		return nil
+	}
+	if err := openInBrowser(path); err != nil {
+		logging.Debugf("Failed to open a browser: %v", err)
+		fmt.Fprintln(w, "Couldn't open a browser, please open this file manually.")
+	}
+	return nil
+}
+
+// headless returns why no browser can be opened in this session, or "" if
+// one probably can
+func headless() string {
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		// A browser opened from an SSH session would show up on the
+		// machine's own screen, not in front of the user
+		if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+			return "running in an SSH session"
+		}
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return "no DISPLAY or WAYLAND_DISPLAY is set"
+		}
+		if _, err := exec.LookPath("xdg-open"); err != nil {
+			return "xdg-open is not installed"
+		}
+	}
+	return ""
+}
+
+// openInBrowser opens the specified file in the default browser
+func openInBrowser(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", path)
+	default: // Linux and others
+		cmd = exec.Command("xdg-open", path)
+	}
+
+	logging.Debugf("Opening browser: %s", strings.Join(cmd.Args, " "))
+	return cmd.Run()
+}
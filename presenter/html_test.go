@@ -0,0 +1,79 @@
+package presenter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+func testTaskDetail() task.TaskDetail {
+	return task.TaskDetail{
+		Task: task.Task{
+			Id:        1,
+			Name:      "Deploy the app",
+			Status:    task.StatusInProgress,
+			CreatedAt: "2026-10-01 09:00:00",
+			Owner:     "alice",
+			Assignees: []task.Assignee{{Member: "alice", Role: task.RoleOwner}, {Member: "bob", Role: task.RoleAssignee}},
+		},
+		Comments: []task.Comment{{Id: 1, TaskId: 1, Author: "bob", Body: "on it", CreatedAt: "2026-10-02 10:00:00"}},
+	}
+}
+
+// TestPagesWorkOffline checks that the pages load nothing from other hosts, so
+// they are styled without network access
+func TestPagesWorkOffline(t *testing.T) {
+	t.Setenv(ThemeDirEnv, t.TempDir())
+	d := testTaskDetail()
+
+	tests := []struct {
+		name   string
+		render func(w *bytes.Buffer) error
+	}{
+		{name: "task", render: func(w *bytes.Buffer) error { return RenderTask(w, d, PageOptions{}) }},
+		{name: "task list", render: func(w *bytes.Buffer) error { return RenderTaskList(w, []task.Task{d.Task}, PageOptions{}) }},
+		{name: "live task list", render: func(w *bytes.Buffer) error {
+			return RenderTaskList(w, []task.Task{d.Task}, PageOptions{Live: true})
+		}},
+		{name: "inline task list", render: func(w *bytes.Buffer) error {
+			return RenderTaskList(w, []task.Task{d.Task}, PageOptions{InlineAssets: true})
+		}},
+		{name: "workload", render: func(w *bytes.Buffer) error {
+			return RenderWorkload(w, []task.MemberWorkload{{Member: "alice", Open: 1}})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.render(&buf); err != nil {
+				t.Fatal(err)
+			}
+			page := buf.String()
+			if strings.Contains(page, "://") {
+				t.Errorf("page links to another host:\n%s", page)
+			}
+			if !strings.Contains(page, ".card-body") && !strings.Contains(page, "assets/css/base.css") {
+				t.Errorf("page has no stylesheet:\n%s", page)
+			}
+		})
+	}
+}
+
+func TestExportSiteCopiesAssets(t *testing.T) {
+	t.Setenv(ThemeDirEnv, t.TempDir())
+	dir := t.TempDir()
+	if err := ExportSite(dir, []task.TaskDetail{testTaskDetail()}, []task.Member{{Name: "alice"}, {Name: "bob"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"index.html", "task-1.html", "member-alice.html", "assets/css/base.css", "assets/css/tasks_list.css", "assets/js/tasks_list.js"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("export is missing %s: %v", name, err)
+		}
+	}
+}
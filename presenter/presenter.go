@@ -0,0 +1,86 @@
+// Package presenter renders the results of the task service for the CLI
+package presenter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+// Output formats supported by --output
+const (
+	OutputText  = "text"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputCSV   = "csv"
+	OutputTable = "table"
+)
+
+// OutputFormats lists the accepted --output values
+var OutputFormats = []string{OutputText, OutputJSON, OutputYAML, OutputCSV, OutputTable}
+
+// ValidateOutputFormat checks that format is one of OutputFormats
+func ValidateOutputFormat(format string) error {
+	for _, f := range OutputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid output format %q: expected one of %s", format, strings.Join(OutputFormats, ", "))
+}
+
+// Presenter renders service results
+type Presenter interface {
+	// Task renders a single task after it was changed. message describes the
+	// change and is only shown in text output.
+	Task(t *task.Task, message string) error
+	// Tasks renders a list of tasks. columns selects the columns to show, see
+	// task.ParseColumns; when empty the default layout is used.
+	Tasks(tasks []task.Task, columns []string) error
+	TaskDetail(detail *task.TaskDetail) error
+	SearchResults(results []task.SearchResult) error
+	// Members renders the member list. current is the current member's name,
+	// or empty when none is set.
+	Members(members []task.Member, current string) error
+	// OrphanedMembers renders the problems found by task doctor
+	OrphanedMembers(orphans []task.OrphanedMember) error
+	// MyWork renders the current member's work for task me
+	MyWork(work *task.MyWork) error
+	// Workload renders the report of task report workload
+	Workload(workload []task.MemberWorkload) error
+	// Stats renders the throughput of task stats
+	Stats(stats *task.Stats) error
+	// Standup and Digest render the summaries of task standup and task
+	// digest. Text output uses the text/template at templatePath, or the
+	// default template when it is empty.
+	Standup(standup *task.Standup, templatePath string) error
+	Digest(digest *task.Digest, templatePath string) error
+	// Message reports the outcome of a command that has no data to show
+	Message(format string, args ...interface{}) error
+}
+
+// statusText returns the display name of a task status
+func statusText(status string) string {
+	switch status {
+	case task.StatusDone:
+		return "Completed"
+	case task.StatusInProgress:
+		return "In Progress"
+	case task.StatusBlocked:
+		return "Blocked"
+	}
+	return "Pending"
+}
+
+// New returns the presenter for an output format
+func New(w io.Writer, format string) (Presenter, error) {
+	if err := ValidateOutputFormat(format); err != nil {
+		return nil, err
+	}
+	if format == OutputText {
+		return NewTextPresenter(w), nil
+	}
+	return NewDataPresenter(w, format), nil
+}
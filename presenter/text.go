@@ -0,0 +1,434 @@
+package presenter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+// TextPresenter renders results as human readable text
+type TextPresenter struct {
+	w io.Writer
+}
+
+func NewTextPresenter(w io.Writer) Presenter {
+	return &TextPresenter{w: w}
+}
+
+func (p *TextPresenter) Task(t *task.Task, message string) error {
+	_, err := fmt.Fprintln(p.w, message)
+	return err
+}
+
+func (p *TextPresenter) Tasks(tasks []task.Task, columns []string) error {
+	if len(tasks) == 0 {
+		_, err := fmt.Fprintln(p.w, "No tasks found.")
+		return err
+	}
+
+	if len(columns) > 0 {
+		return p.taskColumns(tasks, columns)
+	}
+
+	fmt.Fprintln(p.w, "Tasks:")
+	for _, t := range tasks {
+		line := fmt.Sprintf("%s [%d] %s", checkbox(t), t.Id, t.Name)
+		if assignees := assigneeList(t); assignees != "" {
+			line += " (" + assignees + ")"
+		}
+		if _, err := fmt.Fprintln(p.w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskColumns prints the selected columns of each task as an aligned table
+func (p *TextPresenter) taskColumns(tasks []task.Task, columns []string) error {
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, t := range tasks {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			values[i] = columnValue(t, column)
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
+
+func (p *TextPresenter) TaskDetail(detail *task.TaskDetail) error {
+	fmt.Fprintf(p.w, "Task ID: %d\n", detail.Id)
+	fmt.Fprintf(p.w, "Name: %s\n", detail.Name)
+	fmt.Fprintf(p.w, "Status: %s\n", statusText(detail.Status))
+	fmt.Fprintf(p.w, "Created At: %s\n", detail.CreatedAt)
+	if detail.CompletedAt != "" {
+		fmt.Fprintf(p.w, "Completed At: %s\n", detail.CompletedAt)
+	}
+	if detail.UpdatedBy != "" {
+		fmt.Fprintf(p.w, "Updated At: %s by %s\n", detail.UpdatedAt, detail.UpdatedBy)
+	}
+	if detail.DueDate != "" {
+		overdue := ""
+		if detail.Overdue(time.Now()) {
+			overdue = " (overdue)"
+		}
+		fmt.Fprintf(p.w, "Due: %s%s\n", detail.DueDate, overdue)
+	}
+	fmt.Fprintf(p.w, "Owner: %s\n", detail.Owner)
+	if assignees := assigneeList(detail.Task); assignees != "" {
+		fmt.Fprintf(p.w, "Assignees: %s\n", assignees)
+	}
+	if detail.Description != "" {
+		fmt.Fprintf(p.w, "Description: %s\n", detail.Description)
+	}
+
+	if len(detail.Comments) > 0 {
+		fmt.Fprintln(p.w, "Comments:")
+		for _, comment := range detail.Comments {
+			fmt.Fprintf(p.w, "- %s (%s): %s\n", comment.Author, comment.CreatedAt, comment.Body)
+		}
+	}
+	return nil
+}
+
+func (p *TextPresenter) SearchResults(results []task.SearchResult) error {
+	if len(results) == 0 {
+		_, err := fmt.Fprintln(p.w, "No matching tasks found.")
+		return err
+	}
+
+	start, end := "**", "**"
+	if f, ok := p.w.(*os.File); ok && isTerminal(f) {
+		start, end = "\033[1;33m", "\033[0m"
+	}
+	render := strings.NewReplacer(task.HighlightStart, start, task.HighlightEnd, end)
+
+	for _, result := range results {
+		fmt.Fprintf(p.w, "%s [%d] %s\n", checkbox(result.Task), result.Task.Id, render.Replace(result.Name))
+		if result.Snippet != "" {
+			fmt.Fprintf(p.w, "      %s\n", render.Replace(result.Snippet))
+		}
+	}
+	return nil
+}
+
+func (p *TextPresenter) Members(members []task.Member, current string) error {
+	if len(members) == 0 {
+		_, err := fmt.Fprintln(p.w, "No members found.")
+		return err
+	}
+
+	if current != "" {
+		fmt.Fprintf(p.w, "Current user: %s\n\n", current)
+	}
+
+	fmt.Fprintln(p.w, "Members:")
+	for _, member := range members {
+		isCurrent := ""
+		if current == member.Name {
+			isCurrent = " (you)"
+		}
+		if !member.Active() {
+			fmt.Fprintf(p.w, "- %s%s (%s, joined: %s, deactivated: %s)\n", member.Name, isCurrent, member.Role, member.CreatedAt, member.DeactivatedAt)
+			continue
+		}
+		fmt.Fprintf(p.w, "- %s%s (%s, joined: %s)\n", member.Name, isCurrent, member.Role, member.CreatedAt)
+	}
+	return nil
+}
+
+func (p *TextPresenter) OrphanedMembers(orphans []task.OrphanedMember) error {
+	if len(orphans) == 0 {
+		_, err := fmt.Fprintln(p.w, "No problems found.")
+		return err
+	}
+
+	for _, o := range orphans {
+		fmt.Fprintf(p.w, "Task %d %q: %s %s is not a member", o.TaskID, o.Task, o.Role, o.Member)
+		if o.Suggestion != "" {
+			fmt.Fprintf(p.w, " (did you mean %s?)", o.Suggestion)
+		}
+		fmt.Fprintln(p.w)
+	}
+	_, err := fmt.Fprintln(p.w, "\nAdd the missing members with add-member, or reassign the tasks with assign and unassign.")
+	return err
+}
+
+// MyWork prints each non-empty section of the member's work as a compact list
+func (p *TextPresenter) MyWork(work *task.MyWork) error {
+	sections := []struct {
+		title string
+		tasks []task.Task
+	}{
+		{"Overdue", work.Overdue},
+		{"Blocked", work.Blocked},
+		{"Owned", work.Owned},
+		{"Collaborating", work.Collaborating},
+		{"Completed this week", work.CompletedThisWeek},
+	}
+
+	empty := true
+	for _, section := range sections {
+		if len(section.tasks) == 0 {
+			continue
+		}
+		if !empty {
+			fmt.Fprintln(p.w)
+		}
+		empty = false
+
+		fmt.Fprintf(p.w, "%s (%d):\n", section.title, len(section.tasks))
+		for _, t := range section.tasks {
+			line := fmt.Sprintf("%s [%d] %s", checkbox(t), t.Id, t.Name)
+			if details := workDetails(t, work.Member); details != "" {
+				line += " (" + details + ")"
+			}
+			if _, err := fmt.Fprintln(p.w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if empty {
+		_, err := fmt.Fprintf(p.w, "Nothing assigned to %s.\n", work.Member)
+		return err
+	}
+	return nil
+}
+
+// workDetails describes a task in task me: its priority, due date and the
+// member's role when they don't own it
+func workDetails(t task.Task, member string) string {
+	var details []string
+	if t.Priority != task.PriorityNone {
+		details = append(details, task.PriorityName(t.Priority))
+	}
+	if t.DueDate != "" && t.Status != "done" {
+		details = append(details, "due "+t.DueDate)
+	}
+	if role := t.Role(member); role != task.RoleOwner {
+		details = append(details, role)
+	}
+	return strings.Join(details, ", ")
+}
+
+// Workload prints the report as an aligned table with a column per status
+func (p *TextPresenter) Workload(workload []task.MemberWorkload) error {
+	if len(workload) == 0 {
+		_, err := fmt.Fprintln(p.w, "No members found.")
+		return err
+	}
+
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	header := []string{"MEMBER", "OPEN", "OWNED", "COLLAB", "OVERDUE", "AVG AGE", "HIGH", "MEDIUM", "LOW", "NONE"}
+	for _, c := range workload[0].ByStatus {
+		header = append(header, strings.ToUpper(c.Status))
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for _, m := range workload {
+		age := "-"
+		if m.Open > 0 {
+			age = fmt.Sprintf("%.1fd", m.AverageAgeDays)
+		}
+		values := []string{m.Member,
+			strconv.Itoa(m.Open), strconv.Itoa(m.Owned), strconv.Itoa(m.Collaborating), strconv.Itoa(m.Overdue), age,
+			strconv.Itoa(m.ByPriority.High), strconv.Itoa(m.ByPriority.Medium), strconv.Itoa(m.ByPriority.Low), strconv.Itoa(m.ByPriority.None)}
+		for _, c := range m.ByStatus {
+			values = append(values, strconv.Itoa(c.Count))
+		}
+		fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	return w.Flush()
+}
+
+// burndownWidth is the width of the longest bar of the burndown chart
+const burndownWidth = 40
+
+// Stats renders the totals, lead and cycle times, and a table of the periods
+// with a bar chart of the open tasks
+func (p *TextPresenter) Stats(stats *task.Stats) error {
+	scope := "all tasks"
+	if stats.Member != "" {
+		scope = "tasks of " + stats.Member
+	}
+	fmt.Fprintf(p.w, "Stats for %s from %s to %s, per %s\n", scope, stats.Since, stats.Until, stats.Interval)
+	fmt.Fprintf(p.w, "Created: %d  Completed: %d\n", stats.Created, stats.Completed)
+	fmt.Fprintf(p.w, "Lead time (created to done):      %s\n", durationSummary(stats.LeadTime))
+	fmt.Fprintf(p.w, "Cycle time (in progress to done): %s\n\n", durationSummary(stats.CycleTime))
+
+	maxOpen := 0
+	for _, point := range stats.Series {
+		maxOpen = max(maxOpen, point.Open)
+	}
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PERIOD\tCREATED\tCOMPLETED\tOPEN\tBURNDOWN")
+	for _, point := range stats.Series {
+		bar := ""
+		if maxOpen > 0 {
+			bar = strings.Repeat("█", (point.Open*burndownWidth+maxOpen-1)/maxOpen)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", point.Period, point.Created, point.Completed, point.Open, bar)
+	}
+	return w.Flush()
+}
+
+// durationSummary returns the median and 90th percentile of durations, in
+// days when they are long
+func durationSummary(d task.DurationStats) string {
+	if d.Count == 0 {
+		return "no data"
+	}
+	format := func(hours float64) string {
+		if hours >= 48 {
+			return fmt.Sprintf("%.1fd", hours/24)
+		}
+		return fmt.Sprintf("%.1fh", hours)
+	}
+	tasks := "tasks"
+	if d.Count == 1 {
+		tasks = "task"
+	}
+	return fmt.Sprintf("median %s, p90 %s (%d %s)", format(d.MedianHours), format(d.P90Hours), d.Count, tasks)
+}
+
+// Standup renders the standup with templates/standup.tmpl, see
+// executeTextTemplate
+func (p *TextPresenter) Standup(standup *task.Standup, templatePath string) error {
+	return executeTextTemplate(p.w, "standup.tmpl", templatePath, standup)
+}
+
+// Digest renders the digest as Markdown with templates/digest.md.tmpl, see
+// executeTextTemplate
+func (p *TextPresenter) Digest(digest *task.Digest, templatePath string) error {
+	return executeTextTemplate(p.w, "digest.md.tmpl", templatePath, digest)
+}
+
+// textTemplateFuncs are the functions available to the standup and digest
+// templates in addition to the text/template builtins
+var textTemplateFuncs = texttemplate.FuncMap{
+	// date formats a timestamp as a local YYYY-MM-DD date
+	"date": func(timestamp string) string {
+		return formatTimestamp(timestamp, time.DateOnly)
+	},
+	// datetime formats a timestamp as a local YYYY-MM-DD HH:MM time
+	"datetime": func(timestamp string) string {
+		return formatTimestamp(timestamp, "2006-01-02 15:04")
+	},
+	// weekday returns the name of the day of a YYYY-MM-DD date
+	"weekday": func(date string) string {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return date
+		}
+		return day.Weekday().String()
+	},
+}
+
+// formatTimestamp formats a timestamp in local time, or returns it as it is
+// when it can't be parsed
+func formatTimestamp(timestamp, layout string) string {
+	t, ok := task.ParseTimestamp(timestamp)
+	if !ok {
+		return timestamp
+	}
+	return t.Local().Format(layout)
+}
+
+// executeTextTemplate renders data with the text/template in the file at
+// path, or with the template called name in the theme directory or the
+// embedded templates when path is empty
+func executeTextTemplate(w io.Writer, name, path string, data interface{}) error {
+	tmpl := texttemplate.New(name).Funcs(textTemplateFuncs)
+	var err error
+	if path != "" {
+		var text []byte
+		if text, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("error reading template: %v", err)
+		}
+		tmpl, err = tmpl.Parse(string(text))
+	} else {
+		tmpl, err = tmpl.ParseFS(Files(), "templates/"+name)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing template: %v", err)
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering template: %v", err)
+	}
+	return nil
+}
+
+func (p *TextPresenter) Message(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(p.w, format+"\n", args...)
+	return err
+}
+
+// checkbox returns the status marker shown in front of a task
+func checkbox(t task.Task) string {
+	switch t.Status {
+	case task.StatusDone:
+		return "[✔]"
+	case task.StatusInProgress:
+		return "[~]"
+	case task.StatusBlocked:
+		return "[!]"
+	}
+	return "[ ]"
+}
+
+// columnValue returns the display value of a column for a task
+func columnValue(t task.Task, column string) string {
+	switch column {
+	case "id":
+		return fmt.Sprintf("%d", t.Id)
+	case "name":
+		return t.Name
+	case "status":
+		return t.Status
+	case "priority":
+		return task.PriorityName(t.Priority)
+	case "owner":
+		return t.Owner
+	case "assignees":
+		return assigneeList(t)
+	case "created":
+		return t.CreatedAt
+	case "due":
+		return t.DueDate
+	}
+	return ""
+}
+
+// assigneeList lists the members assigned to a task other than its owner,
+// with their role unless it is assignee, e.g. "alice, bob (reviewer)"
+func assigneeList(t task.Task) string {
+	var names []string
+	for _, a := range t.Assignees {
+		switch a.Role {
+		case task.RoleOwner:
+		case task.RoleAssignee:
+			names = append(names, a.Member)
+		default:
+			names = append(names, a.String())
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,100 @@
+package presenter
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ryuux05/task-cli/public"
+)
+
+// ThemeDirEnv is the environment variable that sets the theme directory
+const ThemeDirEnv = "TASK_CLI_THEME_DIR"
+
+// themeDir returns the directory whose templates/ and assets/ override the
+// embedded ones: $TASK_CLI_THEME_DIR, or task-cli/theme in the user config
+// directory (~/.config on Linux)
+func themeDir() string {
+	if dir := os.Getenv(ThemeDirEnv); dir != "" {
+		return dir
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(config, "task-cli", "theme")
+}
+
+// overlayFS opens files from theme when they exist there, and from base
+// otherwise
+type overlayFS struct {
+	theme fs.FS
+	base  fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	if o.theme != nil {
+		f, err := o.theme.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir lists the files of a directory in both theme and base, so walking
+// the overlay finds the embedded files a theme doesn't replace
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(o.base, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if o.theme == nil {
+		return entries, err
+	}
+	themeEntries, themeErr := fs.ReadDir(o.theme, name)
+	if themeErr != nil {
+		if errors.Is(themeErr, fs.ErrNotExist) {
+			return entries, err
+		}
+		return nil, themeErr
+	}
+
+	byName := map[string]fs.DirEntry{}
+	for _, entry := range entries {
+		byName[entry.Name()] = entry
+	}
+	for _, entry := range themeEntries {
+		byName[entry.Name()] = entry
+	}
+	merged := make([]fs.DirEntry, 0, len(byName))
+	for _, entry := range byName {
+		merged = append(merged, entry)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
+
+// Files returns the templates and assets used by the HTML views. Files in the
+// theme directory replace the embedded files with the same path, so a theme
+// only needs the files it changes.
+func Files() fs.FS {
+	files := overlayFS{base: public.FS}
+	if dir := themeDir(); dir != "" {
+		files.theme = os.DirFS(dir)
+	}
+	return files
+}
+
+// Assets returns the assets/ directory of Files, to serve or copy next to
+// the pages
+func Assets() fs.FS {
+	// Sub only fails for invalid paths
+	assets, _ := fs.Sub(Files(), "assets")
+	return assets
+}
@@ -1,132 +1,284 @@
 document.addEventListener('DOMContentLoaded', function() {
-    // Initialize Bootstrap modals
-    const editModal = new bootstrap.Modal(document.getElementById('editTaskModal'));
-    const deleteModal = new bootstrap.Modal(document.getElementById('deleteConfirmModal'));
-    
-    // Add new task functionality
-    document.getElementById('addNewTask').addEventListener('click', function(e) {
-        e.preventDefault();
-        alert('Add new task functionality will be implemented here.');
-        // In a real app, you would show a form and make an API call to create a new task
-    });
-    
-    // Edit task functionality
-    document.querySelectorAll('.edit-task').forEach(button => {
+    // Pages served by `task serve` change tasks through its API. Other pages
+    // are read-only snapshots and only support filtering.
+    const live = document.body.getAttribute('data-live') === 'true';
+    const taskList = document.querySelector('.task-list');
+
+    // The modals are <dialog> elements, closed by their data-dismiss buttons
+    const addModal = document.getElementById('addTaskModal');
+    const editModal = document.getElementById('editTaskModal');
+    const deleteModal = document.getElementById('deleteConfirmModal');
+    document.querySelectorAll('[data-dismiss="modal"]').forEach(function(button) {
         button.addEventListener('click', function() {
-            const taskId = this.getAttribute('data-task-id');
-            const taskCard = document.querySelector(`.task-card[data-task-id="${taskId}"]`);
-            const taskName = taskCard.querySelector('.card-title').textContent;
-            const taskStatus = taskCard.getAttribute('data-task-status').toLowerCase();
-            
-            document.getElementById('editTaskId').value = taskId;
-            document.getElementById('editTaskName').value = taskName;
-            document.getElementById('editTaskStatus').value = taskStatus === 'completed' ? 'done' : 'pending';
-            
-            editModal.show();
+            button.closest('dialog').close();
         });
     });
-    
-    // Save task changes
-    document.getElementById('saveTaskChanges').addEventListener('click', function() {
-        const taskId = document.getElementById('editTaskId').value;
-        const taskName = document.getElementById('editTaskName').value;
-        const taskStatus = document.getElementById('editTaskStatus').value;
-        
-        // In a real app, you would make an API call here to update the task
-        alert(`Task ${taskId} would be updated to "${taskName}" with status "${taskStatus}"`);
-        
-        // For demo purposes, update the UI directly
-        const taskCard = document.querySelector(`.task-card[data-task-id="${taskId}"]`);
-        taskCard.querySelector('.card-title').textContent = taskName;
-        
-        const statusBadge = taskCard.querySelector('.badge');
-        if (taskStatus === 'done') {
-            statusBadge.textContent = 'Completed';
-            statusBadge.className = 'badge rounded-pill badge-success';
-            taskCard.setAttribute('data-task-status', 'Completed');
-        } else {
-            statusBadge.textContent = 'Pending';
-            statusBadge.className = 'badge rounded-pill badge-warning';
-            taskCard.setAttribute('data-task-status', 'Pending');
+
+    // Send a request to the API and return the decoded response. Errors are
+    // thrown with the message sent by the server.
+    async function api(method, path, body) {
+        const options = { method: method, headers: {} };
+        if (body !== undefined) {
+            options.headers['Content-Type'] = 'application/json';
+            options.body = JSON.stringify(body);
         }
-        
-        editModal.hide();
-    });
-    
-    // Delete task functionality
-    document.querySelectorAll('.delete-task').forEach(button => {
-        button.addEventListener('click', function() {
-            const taskId = this.getAttribute('data-task-id');
-            document.getElementById('deleteTaskId').value = taskId;
-            deleteModal.show();
+
+        const response = await fetch(path, options);
+        if (response.status === 204) {
+            return null;
+        }
+        const data = await response.json();
+        if (!response.ok) {
+            throw new Error(data.error || response.statusText);
+        }
+        return data;
+    }
+
+    function showError(err) {
+        const errorAlert = document.getElementById('errorAlert');
+        errorAlert.textContent = err.message;
+        errorAlert.classList.remove('hidden');
+    }
+
+    function clearError() {
+        document.getElementById('errorAlert').classList.add('hidden');
+    }
+
+    const statusTexts = { done: 'Completed', in_progress: 'In Progress', blocked: 'Blocked' };
+    const statusClasses = { done: 'badge-success', in_progress: 'badge-info', blocked: 'badge-danger' };
+
+    function statusText(status) {
+        return statusTexts[status] || 'Pending';
+    }
+
+    // statusValue is the inverse of statusText
+    function statusValue(text) {
+        const status = Object.keys(statusTexts).find(key => statusTexts[key] === text);
+        return status || 'pending';
+    }
+
+    function findCard(taskId) {
+        return document.querySelector(`.task-card[data-task-id="${taskId}"]`);
+    }
+
+    function actionButton(className, label, taskId) {
+        const button = document.createElement('button');
+        button.className = `btn btn-sm ${className}`;
+        button.setAttribute('data-task-id', taskId);
+        button.textContent = label;
+        return button;
+    }
+
+    // Build a card with the same markup as the template
+    function createCard(task) {
+        const card = document.createElement('div');
+        card.className = 'card task-card';
+        card.setAttribute('data-task-id', task.id);
+        card.innerHTML = `
+            <div class="card-body">
+                <div class="d-flex justify-content-between align-items-center">
+                    <h5 class="card-title"></h5>
+                    <span class="badge rounded-pill"></span>
+                </div>
+                <div class="task-meta text-muted small mb-2"></div>
+                <div class="task-assignees small mb-2"></div>
+                <div class="task-actions"></div>
+            </div>`;
+        card.querySelector('.task-meta').textContent = `Created: ${task.created_at}`;
+        card.querySelector('.task-actions').append(
+            actionButton('btn-outline-primary edit-task', 'Edit', task.id),
+            actionButton('btn-outline-success toggle-status', 'Toggle Status', task.id),
+            actionButton('btn-outline-danger delete-task', 'Delete', task.id)
+        );
+        updateCard(card, task);
+        return card;
+    }
+
+    // List the assignees other than the owner, as the template does
+    function assigneeText(task) {
+        return (task.assignees || [])
+            .filter(a => a.role !== 'owner')
+            .map(a => a.role === 'assignee' ? a.member : `${a.member} (${a.role})`)
+            .join(', ');
+    }
+
+    // Show the name, status and assignees of a task on its card
+    function updateCard(card, task) {
+        const text = statusText(task.status);
+        card.querySelector('.card-title').textContent = task.name;
+        card.querySelector('.task-assignees').textContent = assigneeText(task);
+
+        const statusBadge = card.querySelector('.badge');
+        statusBadge.textContent = text;
+        statusBadge.className = `badge rounded-pill ${statusClasses[task.status] || 'badge-warning'}`;
+
+        card.setAttribute('data-task-status', text);
+        const toggleButton = card.querySelector('.toggle-status');
+        if (toggleButton) {
+            toggleButton.setAttribute('data-task-status', text);
+        }
+    }
+
+    function updateEmptyMessage() {
+        const empty = document.querySelectorAll('.task-card').length === 0;
+        document.getElementById('emptyMessage').classList.toggle('hidden', !empty);
+    }
+
+    if (live) {
+        // Add new task functionality
+        document.getElementById('addNewTask').addEventListener('click', function(e) {
+            e.preventDefault();
+            document.getElementById('addTaskName').value = '';
+            document.getElementById('addTaskPriority').value = '0';
+            document.getElementById('addTaskDescription').value = '';
+            addModal.showModal();
         });
-    });
-    
-    // Confirm delete
-    document.getElementById('confirmDelete').addEventListener('click', function() {
-        const taskId = document.getElementById('deleteTaskId').value;
-        
-        // In a real app, you would make an API call here to delete the task
-        alert(`Task ${taskId} would be deleted`);
-        
-        // For demo purposes, just hide the task card
-        const taskCard = document.querySelector(`.task-card[data-task-id="${taskId}"]`);
-        taskCard.remove();
-        
-        // Update task count
-        const taskCount = document.querySelectorAll('.task-card').length;
-        document.getElementById('task-count').textContent = taskCount;
-        
-        deleteModal.hide();
-    });
-    
-    // Toggle status functionality
-    document.querySelectorAll('.toggle-status').forEach(button => {
-        button.addEventListener('click', function() {
-            const taskId = this.getAttribute('data-task-id');
-            const currentStatus = this.getAttribute('data-task-status');
-            const newStatus = currentStatus === 'Completed' ? 'Pending' : 'Completed';
-            
-            // In a real app, you would make an API call here to update the status
-            alert(`Task ${taskId} status would be changed from "${currentStatus}" to "${newStatus}"`);
-            
-            // For demo purposes, update the UI directly
-            const taskCard = document.querySelector(`.task-card[data-task-id="${taskId}"]`);
-            const statusBadge = taskCard.querySelector('.badge');
-            
-            if (newStatus === 'Completed') {
-                statusBadge.textContent = 'Completed';
-                statusBadge.className = 'badge rounded-pill badge-success';
-            } else {
-                statusBadge.textContent = 'Pending';
-                statusBadge.className = 'badge rounded-pill badge-warning';
+
+        document.getElementById('saveNewTask').addEventListener('click', async function() {
+            try {
+                const task = await api('POST', '/api/tasks', {
+                    name: document.getElementById('addTaskName').value.trim(),
+                    priority: parseInt(document.getElementById('addTaskPriority').value, 10),
+                    description: document.getElementById('addTaskDescription').value
+                });
+                taskList.append(createCard(task));
+                clearError();
+                updateEmptyMessage();
+                applyFilters();
+            } catch (err) {
+                showError(err);
             }
-            
-            this.setAttribute('data-task-status', newStatus);
-            taskCard.setAttribute('data-task-status', newStatus);
+            addModal.close();
         });
-    });
-    
+
+        // The buttons are handled on the list so cards added later work too
+        taskList.addEventListener('click', function(e) {
+            const button = e.target.closest('button');
+            if (!button) {
+                return;
+            }
+            const taskId = button.getAttribute('data-task-id');
+
+            if (button.classList.contains('edit-task')) {
+                const taskCard = findCard(taskId);
+                const taskStatus = taskCard.getAttribute('data-task-status');
+
+                document.getElementById('editTaskId').value = taskId;
+                document.getElementById('editTaskName').value = taskCard.querySelector('.card-title').textContent;
+                document.getElementById('editTaskStatus').value = statusValue(taskStatus);
+                editModal.showModal();
+            } else if (button.classList.contains('delete-task')) {
+                document.getElementById('deleteTaskId').value = taskId;
+                deleteModal.showModal();
+            } else if (button.classList.contains('toggle-status')) {
+                const newStatus = button.getAttribute('data-task-status') === 'Completed' ? 'pending' : 'done';
+                api('PATCH', `/api/tasks/${taskId}`, { status: newStatus })
+                    .then(function(task) {
+                        updateCard(findCard(taskId), task);
+                        clearError();
+                        applyFilters();
+                    })
+                    .catch(showError);
+            }
+        });
+
+        // Save task changes
+        document.getElementById('saveTaskChanges').addEventListener('click', async function() {
+            const taskId = document.getElementById('editTaskId').value;
+            try {
+                const task = await api('PATCH', `/api/tasks/${taskId}`, {
+                    name: document.getElementById('editTaskName').value.trim(),
+                    status: document.getElementById('editTaskStatus').value
+                });
+                updateCard(findCard(taskId), task);
+                clearError();
+                applyFilters();
+            } catch (err) {
+                showError(err);
+            }
+            editModal.close();
+        });
+
+        // Confirm delete
+        document.getElementById('confirmDelete').addEventListener('click', async function() {
+            const taskId = document.getElementById('deleteTaskId').value;
+            try {
+                await api('DELETE', `/api/tasks/${taskId}`);
+                findCard(taskId).remove();
+                clearError();
+                updateEmptyMessage();
+                applyFilters();
+            } catch (err) {
+                showError(err);
+            }
+            deleteModal.close();
+        });
+
+        // The server sends the tasks that changed, including changes made by
+        // other people or processes, so the page stays current
+        const events = new EventSource('/api/events');
+        events.addEventListener('tasks', function(e) {
+            const change = JSON.parse(e.data);
+            change.tasks.forEach(function(task) {
+                const card = findCard(task.id);
+                if (card) {
+                    updateCard(card, task);
+                } else {
+                    taskList.append(createCard(task));
+                }
+            });
+            change.deleted.forEach(function(taskId) {
+                const card = findCard(taskId);
+                if (card) {
+                    card.remove();
+                }
+            });
+
+            // Drop cards the server doesn't know, e.g. ones deleted while
+            // disconnected, and put the others in list order
+            const order = new Set(change.order.map(String));
+            document.querySelectorAll('.task-card').forEach(function(card) {
+                if (!order.has(card.getAttribute('data-task-id'))) {
+                    card.remove();
+                }
+            });
+            change.order.forEach(function(taskId) {
+                const card = findCard(taskId);
+                if (card) {
+                    taskList.append(card);
+                }
+            });
+
+            updateEmptyMessage();
+            applyFilters();
+        });
+        events.addEventListener('failure', function(e) {
+            showError(new Error(JSON.parse(e.data)));
+        });
+    }
+
     // Filter functionality
     const searchInput = document.getElementById('searchInput');
     const statusFilter = document.getElementById('statusFilter');
     const clearFiltersBtn = document.getElementById('clearFilters');
-    
+
     function applyFilters() {
         const searchTerm = searchInput.value.toLowerCase();
-        const statusValue = statusFilter.value;
+        const statusFilterValue = statusFilter.value;
         let visibleCount = 0;
-        
+
         document.querySelectorAll('.task-card').forEach(card => {
             const taskName = card.querySelector('.card-title').textContent.toLowerCase();
             const taskStatus = card.getAttribute('data-task-status').toLowerCase();
-            
+
             // Check if task matches both filters
             const matchesSearch = taskName.includes(searchTerm);
-            const matchesStatus = statusValue === 'all' || 
-                                (statusValue === 'completed' && taskStatus === 'completed') ||
-                                (statusValue === 'pending' && taskStatus === 'pending');
-            
+            const matchesStatus = statusFilterValue === 'all' ||
+                                (statusFilterValue === 'completed' && taskStatus === 'completed') ||
+                                (statusFilterValue === 'in_progress' && taskStatus === 'in progress') ||
+                                (statusFilterValue === 'blocked' && taskStatus === 'blocked') ||
+                                (statusFilterValue === 'pending' && taskStatus === 'pending');
+
             if (matchesSearch && matchesStatus) {
                 card.classList.remove('hidden');
                 visibleCount++;
@@ -134,23 +286,17 @@ document.addEventListener('DOMContentLoaded', function() {
                 card.classList.add('hidden');
             }
         });
-        
+
         // Update visible task count
         document.getElementById('task-count').textContent = visibleCount;
     }
-    
+
     searchInput.addEventListener('input', applyFilters);
     statusFilter.addEventListener('change', applyFilters);
-    
+
     clearFiltersBtn.addEventListener('click', function() {
         searchInput.value = '';
         statusFilter.value = 'all';
-        
-        document.querySelectorAll('.task-card').forEach(card => {
-            card.classList.remove('hidden');
-        });
-        
-        // Reset task count
-        document.getElementById('task-count').textContent = document.querySelectorAll('.task-card').length;
+        applyFilters();
     });
-}); 
\ No newline at end of file
+});
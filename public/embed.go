@@ -0,0 +1,11 @@
+// Package public holds the HTML templates and their assets, and the text
+// templates of task standup and task digest, embedded in the binary so they
+// work wherever it is installed
+package public
+
+import "embed"
+
+// FS contains the templates/ and assets/ directories
+//
+//go:embed templates assets
+var FS embed.FS
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+// pollInterval is how often the tasks are reloaded to find changes to send
+// as events. Polling the database picks up changes made by other processes,
+// e.g. the CLI of a teammate on a shared database.
+const pollInterval = 2 * time.Second
+
+// keepAliveInterval is how often a comment is sent on an idle event stream,
+// so proxies don't close it
+const keepAliveInterval = 30 * time.Second
+
+// changeEvent is the data of a "tasks" event. Tasks holds the tasks that were
+// added or changed, Deleted the IDs of the tasks that are gone, and Order the
+// IDs of all tasks in list order, so cards can be moved to their place.
+type changeEvent struct {
+	Tasks   []task.Task `json:"tasks"`
+	Deleted []int       `json:"deleted"`
+	Order   []int       `json:"order"`
+}
+
+// events streams changes to the tasks as Server-Sent Events. The first event
+// has all tasks, so a page that missed changes, e.g. while reconnecting,
+// catches up.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// Reconnect a little after the next poll when the stream is lost
+	fmt.Fprintf(w, "retry: %d\n\n", (pollInterval + time.Second).Milliseconds())
+	flusher.Flush()
+
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	var previous map[int]task.Task
+	for {
+		current, event, err := s.changes(r.Context(), previous)
+		switch {
+		case err != nil:
+			// The database may be busy or reconnecting; try again next time
+			fmt.Fprintf(w, "event: failure\ndata: %s\n\n", jsonString(err.Error()))
+		case event != nil:
+			data, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "event: tasks\ndata: %s\n\n", data)
+			previous = current
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-poll.C:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// changes loads the tasks and compares them with previous. It returns the
+// tasks by ID, and the event to send or nil when nothing changed.
+func (s *Server) changes(ctx context.Context, previous map[int]task.Task) (map[int]task.Task, *changeEvent, error) {
+	tasks, err := s.service.ListTasks(ctx, task.ListOptions{All: true})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := make(map[int]task.Task, len(tasks))
+	event := &changeEvent{Tasks: []task.Task{}, Deleted: []int{}, Order: make([]int, len(tasks))}
+	for i, t := range tasks {
+		current[t.Id] = t
+		event.Order[i] = t.Id
+		if old, ok := previous[t.Id]; !ok || !reflect.DeepEqual(old, t) {
+			event.Tasks = append(event.Tasks, t)
+		}
+	}
+	for id := range previous {
+		if _, ok := current[id]; !ok {
+			event.Deleted = append(event.Deleted, id)
+		}
+	}
+
+	if previous != nil && len(event.Tasks) == 0 && len(event.Deleted) == 0 {
+		return current, nil, nil
+	}
+	return current, event, nil
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
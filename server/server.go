@@ -0,0 +1,353 @@
+// Package server serves the HTML views and a JSON API over HTTP, so the
+// pages can change tasks through task.TaskService
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryuux05/task-cli/presenter"
+	"github.com/ryuux05/task-cli/task"
+)
+
+// DefaultAddr is the address `task serve` listens on. It is only reachable
+// from this machine.
+const DefaultAddr = "127.0.0.1:7878"
+
+// Server handles the pages and the API
+type Server struct {
+	service task.TaskService
+	mux     *http.ServeMux
+}
+
+// New returns a server for service
+func New(service task.TaskService) *Server {
+	s := &Server{service: service, mux: http.NewServeMux()}
+
+	// Pages
+	s.mux.HandleFunc("GET /{$}", s.tasksPage)
+	s.mux.HandleFunc("GET /tasks/{id}", s.taskPage)
+	s.mux.Handle("GET /assets/", http.StripPrefix("/assets/", http.FileServer(http.FS(presenter.Assets()))))
+
+	// API
+	s.mux.HandleFunc("GET /api/tasks", s.listTasks)
+	s.mux.HandleFunc("POST /api/tasks", s.addTask)
+	s.mux.HandleFunc("GET /api/tasks/{id}", s.getTask)
+	s.mux.HandleFunc("PATCH /api/tasks/{id}", s.updateTask)
+	s.mux.HandleFunc("DELETE /api/tasks/{id}", s.deleteTask)
+	s.mux.HandleFunc("POST /api/tasks/{id}/assignees", s.assignTask)
+	s.mux.HandleFunc("DELETE /api/tasks/{id}/assignees/{member}", s.unassignTask)
+	s.mux.HandleFunc("GET /api/members", s.listMembers)
+	s.mux.HandleFunc("POST /api/members", s.addMember)
+	s.mux.HandleFunc("GET /api/events", s.events)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isLocalHost(r.Host) {
+		http.Error(w, "requests must be addressed to localhost", http.StatusForbidden)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// isLocalHost reports whether host, the Host header of a request, names this
+// machine. Pages on other sites can point their own host name at 127.0.0.1
+// (DNS rebinding), so the address the server listens on isn't enough.
+func isLocalHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	switch strings.ToLower(host) {
+	case "localhost", "127.0.0.1", "::1", "[::1]":
+		return true
+	}
+	return false
+}
+
+// Serve accepts connections on l until ctx is cancelled, then waits briefly
+// for requests in progress
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		done <- srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-done
+}
+
+func (s *Server) tasksPage(w http.ResponseWriter, r *http.Request) {
+	tasks, err := s.service.ListTasks(r.Context(), task.ListOptions{All: true})
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := presenter.RenderTaskList(w, tasks, presenter.PageOptions{Live: true}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) taskPage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	detail, err := s.service.GetTask(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := presenter.RenderTask(w, *detail, presenter.PageOptions{IndexURL: "/"}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// listTasks takes the options of `task list` as query parameters: all,
+// completed, filter, sort, limit and offset
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := task.ListOptions{
+		All:       q.Get("all") == "true" || q.Get("all") == "1",
+		Completed: q.Get("completed") == "true" || q.Get("completed") == "1",
+		Filter:    q.Get("filter"),
+		Sort:      q.Get("sort"),
+	}
+	for name, value := range map[string]*int{"limit": &opts.Limit, "offset": &opts.Offset} {
+		if q.Has(name) {
+			n, err := strconv.Atoi(q.Get(name))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", name, q.Get(name)))
+				return
+			}
+			*value = n
+		}
+	}
+
+	tasks, err := s.service.ListTasks(r.Context(), opts)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if tasks == nil {
+		tasks = []task.Task{}
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (s *Server) addTask(w http.ResponseWriter, r *http.Request) {
+	var data task.NewTaskSchema
+	if !readJSON(w, r, &data) {
+		return
+	}
+
+	added, err := s.service.AddTask(r.Context(), data)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/api/tasks/%d", added.Id))
+	writeJSON(w, http.StatusCreated, added)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	detail, err := s.service.GetTask(r.Context(), id)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// updateTask changes the fields given in the body, see task.UpdateTaskSchema
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+	var data task.UpdateTaskSchema
+	if !readJSON(w, r, &data) {
+		return
+	}
+	data.ID = id
+
+	updated, err := s.service.UpdateTask(r.Context(), data)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.service.DeleteTask(r.Context(), id); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// assignTask gives the members in the body a role, assignee by default
+func (s *Server) assignTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+	var data struct {
+		Members []string `json:"members"`
+		Role    string   `json:"role"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+	if data.Role == "" {
+		data.Role = task.RoleAssignee
+	}
+
+	assigned, err := s.service.AssignTask(r.Context(), id, data.Role, data.Members)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, assigned)
+}
+
+func (s *Server) unassignTask(w http.ResponseWriter, r *http.Request) {
+	id, ok := taskID(w, r)
+	if !ok {
+		return
+	}
+
+	unassigned, err := s.service.UnassignTask(r.Context(), id, []string{r.PathValue("member")})
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, unassigned)
+}
+
+func (s *Server) listMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := s.service.ListMembers(r.Context())
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	if members == nil {
+		members = []task.Member{}
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (s *Server) addMember(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &data) {
+		return
+	}
+	if data.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("member name cannot be empty"))
+		return
+	}
+
+	if err := s.service.AddMember(r.Context(), data.Name); err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, data)
+}
+
+// taskID parses the {id} path parameter, writing an error when it is invalid
+func taskID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid task ID: %s", r.PathValue("id")))
+		return 0, false
+	}
+	return id, true
+}
+
+// readJSON decodes the request body into v, writing an error when it fails.
+// Requests must be sent as application/json, which browsers don't allow
+// other sites to do without asking, so pages elsewhere can't change tasks.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("request body must be application/json"))
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// statusFor returns the HTTP status for an error from the service
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, task.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, task.ErrTaskExists):
+		return http.StatusConflict
+	case errors.Is(err, task.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, task.ErrLoginRequired):
+		return http.StatusUnauthorized
+	case errors.Is(err, task.ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, task.ErrNoCurrentMember):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,274 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ryuux05/task-cli/storage"
+	"github.com/ryuux05/task-cli/task"
+	_ "modernc.org/sqlite"
+)
+
+// newTestServer returns a server for a new database in a temporary
+// directory. alice, an admin, is the current member and owns the task
+// "existing", which bob, a member, is assigned to. carol is a viewer.
+func newTestServer(t *testing.T) (*Server, task.TaskService) {
+	t.Helper()
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "task.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	repo := task.NewTaskRepository(db, storage.NewIdentityFile(filepath.Join(dir, "identities.json")))
+	if err := repo.EnsureSchema(ctx); err != nil {
+		t.Fatal(err)
+	}
+	service := task.NewTaskService(repo)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if err := service.AddMember(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.SetMemberRole(ctx, "carol", task.MemberRoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.AddTask(ctx, task.NewTaskSchema{Name: "existing", Assignees: []string{"bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	return New(service), service
+}
+
+// testOrigin is where the test requests are sent, as a browser on this
+// machine would
+const testOrigin = "http://localhost:7878"
+
+// serve sends a request with a JSON body, if any, to srv
+func serve(srv http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, testOrigin+path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServeHTTPHost(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	tests := []struct {
+		host string
+		want int
+	}{
+		{host: "127.0.0.1:7878", want: http.StatusOK},
+		{host: "localhost:7878", want: http.StatusOK},
+		{host: "LOCALHOST", want: http.StatusOK},
+		{host: "[::1]:7878", want: http.StatusOK},
+		{host: "[::1]", want: http.StatusOK},
+		{host: "evil.example.com:7878", want: http.StatusForbidden},
+		{host: "127.0.0.1.nip.io", want: http.StatusForbidden},
+		{host: "192.168.1.10:7878", want: http.StatusForbidden},
+		{host: "", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("GET /api/tasks with Host %q = %d, want %d", tt.host, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI(t *testing.T) {
+	tests := []struct {
+		name   string
+		as     string
+		method string
+		path   string
+		body   string
+		// contentType replaces application/json when set
+		contentType string
+		want        int
+		// wantBody is a substring of the response
+		wantBody string
+	}{
+		{name: "list", method: "GET", path: "/api/tasks", want: 200, wantBody: `"name":"existing"`},
+		{name: "list with filter", method: "GET", path: "/api/tasks?all=1&filter=status:done", want: 200, wantBody: "[]"},
+		{name: "list with invalid limit", method: "GET", path: "/api/tasks?limit=ten", want: 400, wantBody: `"error":"invalid limit: ten"`},
+		{name: "list with invalid filter", method: "GET", path: "/api/tasks?filter=color:red", want: 400},
+		{name: "get", method: "GET", path: "/api/tasks/1", want: 200, wantBody: `"comments":[]`},
+		{name: "get missing task", method: "GET", path: "/api/tasks/99", want: 404},
+		{name: "get invalid ID", method: "GET", path: "/api/tasks/one", want: 400},
+		{name: "add", method: "POST", path: "/api/tasks", body: `{"name":"new","priority":2}`, want: 201, wantBody: `"priority":2`},
+		{name: "add without name", method: "POST", path: "/api/tasks", body: `{"name":""}`, want: 400},
+		{name: "add existing name", method: "POST", path: "/api/tasks", body: `{"name":"existing"}`, want: 409},
+		{name: "add with unknown field", method: "POST", path: "/api/tasks", body: `{"title":"new"}`, want: 400},
+		{name: "add as form", method: "POST", path: "/api/tasks", body: `name=new`, contentType: "application/x-www-form-urlencoded", want: 415},
+		{name: "add as viewer", as: "carol", method: "POST", path: "/api/tasks", body: `{"name":"new"}`, want: 403},
+		{name: "update", method: "PATCH", path: "/api/tasks/1", body: `{"name":"renamed","status":"done"}`, want: 200, wantBody: `"status":"done"`},
+		{name: "update with unknown status", method: "PATCH", path: "/api/tasks/1", body: `{"status":"later"}`, want: 400},
+		{name: "update missing task", method: "PATCH", path: "/api/tasks/99", body: `{"name":"renamed"}`, want: 404},
+		{name: "delete", method: "DELETE", path: "/api/tasks/1", want: 204},
+		{name: "delete other's task", as: "bob", method: "DELETE", path: "/api/tasks/1", want: 403},
+		{name: "assign", method: "POST", path: "/api/tasks/1/assignees", body: `{"members":["bob"],"role":"reviewer"}`, want: 200, wantBody: `{"member":"bob","role":"reviewer"}`},
+		{name: "assign unknown member", method: "POST", path: "/api/tasks/1/assignees", body: `{"members":["bbo"]}`, want: 400, wantBody: "did you mean bob?"},
+		{name: "unassign", method: "DELETE", path: "/api/tasks/1/assignees/bob", want: 200},
+		{name: "unassign member not assigned", method: "DELETE", path: "/api/tasks/1/assignees/carol", want: 400},
+		{name: "unassign owner", method: "DELETE", path: "/api/tasks/1/assignees/alice", want: 400, wantBody: "assign another owner"},
+		{name: "list members", method: "GET", path: "/api/members", want: 200, wantBody: `"name":"carol","role":"viewer"`},
+		{name: "add member", method: "POST", path: "/api/members", body: `{"name":"dave"}`, want: 201, wantBody: `{"name":"dave"}`},
+		{name: "add member without name", method: "POST", path: "/api/members", body: `{}`, want: 400},
+		{name: "add member as member", as: "bob", method: "POST", path: "/api/members", body: `{"name":"dave"}`, want: 403},
+		{name: "unknown route", method: "GET", path: "/api/nothing", want: 404},
+		{name: "wrong method", method: "PUT", path: "/api/tasks/1", body: `{}`, want: 405},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, service := newTestServer(t)
+			if tt.as != "" {
+				if err := service.SetCurrentMember(context.Background(), tt.as); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			req := httptest.NewRequest(tt.method, testOrigin+tt.path, strings.NewReader(tt.body))
+			switch {
+			case tt.contentType != "":
+				req.Header.Set("Content-Type", tt.contentType)
+			case tt.body != "":
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("%s %s = %d %s, want %d", tt.method, tt.path, rec.Code, rec.Body, tt.want)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("%s %s = %s, want it to contain %s", tt.method, tt.path, rec.Body, tt.wantBody)
+			}
+			if rec.Code >= 400 && rec.Code != 404 && rec.Code != 405 && !strings.Contains(rec.Body.String(), `"error":`) {
+				t.Errorf("%s %s = %s, want a JSON error", tt.method, tt.path, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAPILoginRequired(t *testing.T) {
+	srv, service := newTestServer(t)
+	if err := service.SetPassword(context.Background(), "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(srv, "POST", "/api/tasks", `{"name":"new"}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST /api/tasks without login = %d %s, want 401", rec.Code, rec.Body)
+	}
+}
+
+func TestAddTaskLocation(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	rec := serve(srv, "POST", "/api/tasks", `{"name":"new"}`)
+	var added task.Task
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+		t.Fatal(err)
+	}
+	location := rec.Header().Get("Location")
+	if location != "/api/tasks/2" || added.Id != 2 {
+		t.Fatalf("POST /api/tasks added task %d at %q, want 2 at /api/tasks/2", added.Id, location)
+	}
+	if rec := serve(srv, "GET", location, ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"name":"new"`) {
+		t.Errorf("GET %s = %d %s, want the new task", location, rec.Code, rec.Body)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	srv, service := newTestServer(t)
+	ctx := context.Background()
+
+	// The first event has all tasks
+	current, event, err := srv.changes(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event == nil || len(event.Tasks) != 1 || len(event.Deleted) != 0 || len(event.Order) != 1 {
+		t.Fatalf("first event = %+v, want the existing task", event)
+	}
+
+	if _, event, err = srv.changes(ctx, current); err != nil || event != nil {
+		t.Fatalf("event without changes = %+v, %v, want none", event, err)
+	}
+
+	added, err := service.AddTask(ctx, task.NewTaskSchema{Name: "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.DeleteTask(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, event, err = srv.changes(ctx, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event == nil || len(event.Tasks) != 1 || event.Tasks[0].Id != added.Id ||
+		len(event.Deleted) != 1 || event.Deleted[0] != 1 || len(event.Order) != 1 {
+		t.Errorf("event after adding task %d and deleting task 1 = %+v", added.Id, event)
+	}
+}
+
+func TestEventsStream(t *testing.T) {
+	srv, _ := newTestServer(t)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() != "event: tasks" {
+			continue
+		}
+		scanner.Scan()
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		var event changeEvent
+		if !ok || json.Unmarshal([]byte(data), &event) != nil {
+			t.Fatalf("event data = %q, want a JSON change event", scanner.Text())
+		}
+		if len(event.Tasks) != 1 || event.Tasks[0].Name != "existing" {
+			t.Errorf("first event = %+v, want the existing task", event)
+		}
+		return
+	}
+	t.Fatalf("stream ended without a tasks event: %v", scanner.Err())
+}
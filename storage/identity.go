@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// IdentityFileEnv is the environment variable that sets the identity file
+const IdentityFileEnv = "TASK_CLI_IDENTITY_FILE"
+
+// IdentityFile keeps the member the local user is in each database, and
+// their login session, in a JSON file, so it isn't shared with other people
+// using the same database
+type IdentityFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// identities is the content of an identity file
+type identities struct {
+	// Databases maps a database to the local user's member name in it
+	Databases map[string]string `json:"databases"`
+	// Sessions maps a database to the session token of the last login
+	Sessions map[string]string `json:"sessions,omitempty"`
+}
+
+func NewIdentityFile(path string) *IdentityFile {
+	return &IdentityFile{path: path}
+}
+
+// DefaultIdentityFile returns the identity file set by $TASK_CLI_IDENTITY_FILE,
+// or task-cli/identities.json in the user config directory (~/.config on
+// Linux)
+func DefaultIdentityFile() (*IdentityFile, error) {
+	if path := os.Getenv(IdentityFileEnv); path != "" {
+		return NewIdentityFile(path), nil
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config directory: %v", err)
+	}
+	return NewIdentityFile(filepath.Join(config, "task-cli", "identities.json")), nil
+}
+
+// Identity returns the member name for a database, or "" when none is set
+func (f *IdentityFile) Identity(database string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	return ids.Databases[database], nil
+}
+
+// SetIdentity sets the member name for a database
+func (f *IdentityFile) SetIdentity(database, member string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids, err := f.load()
+	if err != nil {
+		return err
+	}
+	ids.Databases[database] = member
+	return f.save(ids)
+}
+
+// Session returns the session token for a database, or "" when there is none
+func (f *IdentityFile) Session(database string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	return ids.Sessions[database], nil
+}
+
+// SetSession sets the session token for a database. An empty token removes
+// the session.
+func (f *IdentityFile) SetSession(database, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids, err := f.load()
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		delete(ids.Sessions, database)
+	} else {
+		ids.Sessions[database] = token
+	}
+	return f.save(ids)
+}
+
+// load reads the file. A missing file has no identities.
+func (f *IdentityFile) load() (*identities, error) {
+	ids := &identities{}
+	data, err := os.ReadFile(f.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read identity file: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, ids); err != nil {
+			return nil, fmt.Errorf("failed to parse identity file %s: %v", f.path, err)
+		}
+	}
+	if ids.Databases == nil {
+		ids.Databases = map[string]string{}
+	}
+	if ids.Sessions == nil {
+		ids.Sessions = map[string]string{}
+	}
+	return ids, nil
+}
+
+// save replaces the file, so that it is never left half written
+func (f *IdentityFile) save(ids *identities) error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".identities-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write identity file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write identity file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write identity file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write identity file: %v", err)
+	}
+	return nil
+}
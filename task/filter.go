@@ -0,0 +1,229 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter operators supported by the filter language
+const (
+	OpEqual        = "="
+	OpNotEqual     = "!="
+	OpGreater      = ">"
+	OpGreaterEqual = ">="
+	OpLess         = "<"
+	OpLessEqual    = "<="
+	OpContains     = "~"
+	OpNotContains  = "!~"
+)
+
+// filterFields lists the fields that can be used in a filter expression
+var filterFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"status":       true,
+	"priority":     true,
+	"owner":        true,
+	"assignee":     true,
+	"reviewer":     true,
+	"watcher":      true,
+	"collaborator": true,
+	"created":      true,
+	"due":          true,
+}
+
+// memberFields are the filter fields whose value is a member name, so they
+// accept "me". collaborator is the old name of assignee.
+var memberFields = []string{"owner", "assignee", "reviewer", "watcher", "collaborator"}
+
+// FilterCondition is a single comparison in a filter expression, e.g. owner:me
+type FilterCondition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// TaskFilter is a parsed filter expression. All conditions must match.
+type TaskFilter struct {
+	Conditions []FilterCondition
+}
+
+// HasField reports whether the filter contains a condition on the given field
+func (f TaskFilter) HasField(field string) bool {
+	for _, c := range f.Conditions {
+		if c.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a condition to the filter
+func (f *TaskFilter) Add(field, op, value string) {
+	f.Conditions = append(f.Conditions, FilterCondition{Field: field, Op: op, Value: value})
+}
+
+// ResolveMember replaces the "me" placeholder in the conditions on members
+// with the given member name
+func (f *TaskFilter) ResolveMember(name string) {
+	for i, c := range f.Conditions {
+		if containsString(memberFields, c.Field) && c.Value == "me" {
+			f.Conditions[i].Value = name
+		}
+	}
+}
+
+// usesMe reports whether any condition refers to the current member
+func (f TaskFilter) usesMe() bool {
+	for _, c := range f.Conditions {
+		if containsString(memberFields, c.Field) && c.Value == "me" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFilter parses a filter expression such as
+//
+//	status:pending owner:me assignee:alice created>2026-10-01 name~"deploy"
+//
+// Terms are separated by whitespace and must all match. Values containing
+// spaces can be wrapped in double quotes.
+func ParseFilter(expr string) (TaskFilter, error) {
+	var filter TaskFilter
+
+	terms, err := splitFilterTerms(expr)
+	if err != nil {
+		return filter, err
+	}
+
+	for _, term := range terms {
+		cond, err := parseFilterTerm(term)
+		if err != nil {
+			return filter, err
+		}
+		filter.Conditions = append(filter.Conditions, cond)
+	}
+
+	return filter, nil
+}
+
+// splitFilterTerms splits an expression on whitespace, keeping quoted values together
+func splitFilterTerms(expr string) ([]string, error) {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuotes:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in filter: %s", expr)
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+
+	return terms, nil
+}
+
+// parseFilterTerm parses a single field/operator/value term
+func parseFilterTerm(term string) (FilterCondition, error) {
+	idx := strings.IndexAny(term, ":=!<>~")
+	if idx <= 0 {
+		return FilterCondition{}, fmt.Errorf("invalid filter term %q: expected <field><op><value>", term)
+	}
+
+	field := strings.ToLower(term[:idx])
+	rest := term[idx:]
+
+	var op string
+	switch {
+	case strings.HasPrefix(rest, "!="):
+		op, rest = OpNotEqual, rest[2:]
+	case strings.HasPrefix(rest, "!~"):
+		op, rest = OpNotContains, rest[2:]
+	case strings.HasPrefix(rest, ">="):
+		op, rest = OpGreaterEqual, rest[2:]
+	case strings.HasPrefix(rest, "<="):
+		op, rest = OpLessEqual, rest[2:]
+	case strings.HasPrefix(rest, ":"), strings.HasPrefix(rest, "="):
+		op, rest = OpEqual, rest[1:]
+	case strings.HasPrefix(rest, ">"):
+		op, rest = OpGreater, rest[1:]
+	case strings.HasPrefix(rest, "<"):
+		op, rest = OpLess, rest[1:]
+	case strings.HasPrefix(rest, "~"):
+		op, rest = OpContains, rest[1:]
+	default:
+		return FilterCondition{}, fmt.Errorf("invalid operator in filter term %q", term)
+	}
+
+	value := rest
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+
+	cond := FilterCondition{Field: field, Op: op, Value: value}
+	if err := cond.validate(); err != nil {
+		return FilterCondition{}, err
+	}
+
+	return cond, nil
+}
+
+// validate checks that the field is known and the operator makes sense for it
+func (c FilterCondition) validate() error {
+	if !filterFields[c.Field] {
+		return fmt.Errorf("unknown filter field %q", c.Field)
+	}
+
+	if c.Value == "" {
+		return fmt.Errorf("missing value for filter field %q", c.Field)
+	}
+
+	switch c.Field {
+	case "id":
+		if c.Op == OpContains || c.Op == OpNotContains {
+			return fmt.Errorf("operator %s is not supported for id", c.Op)
+		}
+		if _, err := strconv.Atoi(c.Value); err != nil {
+			return fmt.Errorf("invalid task ID in filter: %s", c.Value)
+		}
+	case "priority":
+		if c.Op == OpContains || c.Op == OpNotContains {
+			return fmt.Errorf("operator %s is not supported for priority", c.Op)
+		}
+		if _, err := ParsePriority(c.Value); err != nil {
+			return err
+		}
+	case "created", "due":
+		if c.Op == OpContains || c.Op == OpNotContains {
+			return fmt.Errorf("operator %s is not supported for %s", c.Op, c.Field)
+		}
+		if _, err := time.Parse("2006-01-02", c.Value); err != nil {
+			return fmt.Errorf("invalid date in filter (expected YYYY-MM-DD): %s", c.Value)
+		}
+	case "status", "owner", "assignee", "reviewer", "watcher", "collaborator", "name":
+		if c.Op != OpEqual && c.Op != OpNotEqual && c.Op != OpContains && c.Op != OpNotContains {
+			return fmt.Errorf("operator %s is not supported for %s", c.Op, c.Field)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,265 @@
+package task
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    []FilterCondition
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			expr: "  ",
+		},
+		{
+			name: "several terms",
+			expr: "status:pending owner:me created>2026-10-01",
+			want: []FilterCondition{
+				{Field: "status", Op: OpEqual, Value: "pending"},
+				{Field: "owner", Op: OpEqual, Value: "me"},
+				{Field: "created", Op: OpGreater, Value: "2026-10-01"},
+			},
+		},
+		{
+			name: "operators",
+			expr: "id=1 id!=2 id>3 id>=4 id<5 id<=6 name~a name!~b",
+			want: []FilterCondition{
+				{Field: "id", Op: OpEqual, Value: "1"},
+				{Field: "id", Op: OpNotEqual, Value: "2"},
+				{Field: "id", Op: OpGreater, Value: "3"},
+				{Field: "id", Op: OpGreaterEqual, Value: "4"},
+				{Field: "id", Op: OpLess, Value: "5"},
+				{Field: "id", Op: OpLessEqual, Value: "6"},
+				{Field: "name", Op: OpContains, Value: "a"},
+				{Field: "name", Op: OpNotContains, Value: "b"},
+			},
+		},
+		{
+			name: "field names are case insensitive",
+			expr: "Status:done",
+			want: []FilterCondition{{Field: "status", Op: OpEqual, Value: "done"}},
+		},
+		{
+			name: "quoted value with spaces",
+			expr: `name~"deploy the app"  owner:alice`,
+			want: []FilterCondition{
+				{Field: "name", Op: OpContains, Value: "deploy the app"},
+				{Field: "owner", Op: OpEqual, Value: "alice"},
+			},
+		},
+		{
+			name: "backslashes and wildcards are kept literally",
+			expr: `name~50%_off\`,
+			want: []FilterCondition{{Field: "name", Op: OpContains, Value: `50%_off\`}},
+		},
+		{
+			name: "priority and due",
+			expr: "priority>=medium due<2026-11-01",
+			want: []FilterCondition{
+				{Field: "priority", Op: OpGreaterEqual, Value: "medium"},
+				{Field: "due", Op: OpLess, Value: "2026-11-01"},
+			},
+		},
+		{
+			name: "member roles",
+			expr: "assignee:bob reviewer!=me watcher~car collaborator:bob",
+			want: []FilterCondition{
+				{Field: "assignee", Op: OpEqual, Value: "bob"},
+				{Field: "reviewer", Op: OpNotEqual, Value: "me"},
+				{Field: "watcher", Op: OpContains, Value: "car"},
+				{Field: "collaborator", Op: OpEqual, Value: "bob"},
+			},
+		},
+		{name: "unterminated quote", expr: `name~"deploy`, wantErr: true},
+		{name: "unknown field", expr: "color:red", wantErr: true},
+		{name: "missing field", expr: ":pending", wantErr: true},
+		{name: "missing operator", expr: "pending", wantErr: true},
+		{name: "missing value", expr: "status:", wantErr: true},
+		{name: "empty quoted value", expr: `name:""`, wantErr: true},
+		{name: "invalid id", expr: "id:abc", wantErr: true},
+		{name: "contains on id", expr: "id~1", wantErr: true},
+		{name: "invalid priority", expr: "priority:urgent", wantErr: true},
+		{name: "invalid date", expr: "due<tomorrow", wantErr: true},
+		{name: "ordering on member", expr: "owner>alice", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseFilter(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseFilter(%q) = %+v, want an error", tt.expr, filter.Conditions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(filter.Conditions, tt.want) {
+				t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.expr, filter.Conditions, tt.want)
+			}
+		})
+	}
+}
+
+// newFilterTestDB creates a database with the tables the filter clause
+// queries and three tasks:
+//
+//	1 "Deploy the app"  pending high   alice  2026-10-01  due 2026-10-20  assignee bob, reviewer carol
+//	2 "50% off_sale"    done    none   bob    2026-10-05  no due date     watcher alice, reviewer a_b
+//	3 `fix C:\tmp`      pending medium alice  2026-10-10  due 2026-11-01  assignee carol, reviewer axb
+func newFilterTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "task.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	statements := []string{
+		`CREATE TABLE status (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE)`,
+		`CREATE TABLE tasks (
+			id INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 0,
+			owner TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			due_date TEXT
+		)`,
+		`CREATE TABLE task_assignees (task_id INTEGER NOT NULL, member TEXT NOT NULL, role TEXT NOT NULL)`,
+		`INSERT INTO status (id, name) VALUES (1, 'pending'), (2, 'done')`,
+		`INSERT INTO tasks (id, name, status, priority, owner, created_at, due_date) VALUES
+			(1, 'Deploy the app', 1, 3, 'alice', '2026-10-01 09:00:00', '2026-10-20'),
+			(2, '50% off_sale', 2, 0, 'bob', '2026-10-05 23:59:59', NULL),
+			(3, 'fix C:\tmp', 1, 2, 'alice', '2026-10-10 00:00:00', '2026-11-01')`,
+		`INSERT INTO task_assignees (task_id, member, role) VALUES
+			(1, 'bob', 'assignee'), (1, 'carol', 'reviewer'),
+			(2, 'alice', 'watcher'), (2, 'a_b', 'reviewer'),
+			(3, 'carol', 'assignee'), (3, 'axb', 'reviewer')`,
+	}
+	for _, stmt := range statements {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%v\n%s", err, stmt)
+		}
+	}
+	return db
+}
+
+// filterTaskIDs returns the IDs of the tasks in db matching filter
+func filterTaskIDs(db *sql.DB, filter TaskFilter) ([]int, error) {
+	where, args, err := buildFilterClause(filter)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT t.id FROM tasks t JOIN status s ON t.status = s.id"
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := db.Query(query+" ORDER BY t.id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func TestBuildFilterClause(t *testing.T) {
+	db := newFilterTestDB(t)
+
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{expr: "", want: []int{1, 2, 3}},
+		{expr: "status:pending", want: []int{1, 3}},
+		{expr: "status!=pending", want: []int{2}},
+		{expr: "owner:alice status:pending id>1", want: []int{3}},
+		{expr: "id=2", want: []int{2}},
+		{expr: "id<3", want: []int{1, 2}},
+		{expr: "id>=2", want: []int{2, 3}},
+		{expr: "id<=1", want: []int{1}},
+		{expr: "priority>=medium", want: []int{1, 3}},
+		{expr: "priority:none", want: []int{2}},
+		{expr: "priority<3", want: []int{2, 3}},
+		{expr: "created>2026-10-01", want: []int{2, 3}},
+		{expr: "created<=2026-10-05", want: []int{1, 2}},
+		{expr: "due<2026-10-31", want: []int{1}},
+		{expr: "due>=2026-10-20", want: []int{1, 3}},
+		{expr: "name~DEPLOY", want: []int{1}},
+		{expr: `name~"the app"`, want: []int{1}},
+		{expr: "name!~deploy", want: []int{2, 3}},
+		// LIKE wildcards and the escape character match only themselves
+		{expr: "name~%", want: []int{2}},
+		{expr: "name~_", want: []int{2}},
+		{expr: "name!~%", want: []int{1, 3}},
+		{expr: `name~C:\`, want: []int{3}},
+		{expr: "owner~a_", want: []int{}},
+		// Member roles match any member with the role, or none for != and !~
+		{expr: "assignee:bob", want: []int{1}},
+		{expr: "collaborator:bob", want: []int{1}},
+		{expr: "assignee!=bob", want: []int{2, 3}},
+		{expr: "assignee~car", want: []int{3}},
+		{expr: "reviewer~a_b", want: []int{2}},
+		{expr: "reviewer!~a_", want: []int{1, 3}},
+		{expr: "watcher:alice", want: []int{2}},
+		{expr: "watcher!=alice", want: []int{1, 3}},
+		{expr: "assignee:carol reviewer~x", want: []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := ParseFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+			}
+			got, err := filterTaskIDs(db, filter)
+			if err != nil {
+				t.Fatalf("filter %q: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter %q matched tasks %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilterClauseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cond FilterCondition
+	}{
+		{name: "unknown field", cond: FilterCondition{Field: "color", Op: OpEqual, Value: "red"}},
+		{name: "unknown operator", cond: FilterCondition{Field: "name", Op: "==", Value: "x"}},
+		{name: "ordering on assignee", cond: FilterCondition{Field: "assignee", Op: OpGreater, Value: "bob"}},
+		{name: "invalid id", cond: FilterCondition{Field: "id", Op: OpEqual, Value: "one"}},
+		{name: "invalid priority", cond: FilterCondition{Field: "priority", Op: OpEqual, Value: "urgent"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := TaskFilter{Conditions: []FilterCondition{tt.cond}}
+			if clause, _, err := buildFilterClause(filter); err == nil {
+				t.Errorf("buildFilterClause(%+v) = %q, want an error", tt.cond, clause)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		member   string
+		password string
+		ttl      time.Duration
+		wantErr  error
+	}{
+		{name: "right password", member: "bob", password: "correct horse"},
+		{name: "custom session length", member: "bob", password: "correct horse", ttl: time.Hour},
+		{name: "wrong password", member: "bob", password: "wrong horse", wantErr: ErrPermissionDenied},
+		{name: "no password", member: "carol", password: "correct horse", wantErr: ErrInvalidInput},
+		{name: "unknown member", member: "dave", password: "correct horse", wantErr: ErrUnknownMember},
+		{name: "session too long", member: "bob", password: "correct horse", ttl: 48 * time.Hour, wantErr: ErrInvalidInput},
+		{name: "negative session length", member: "bob", password: "correct horse", ttl: -time.Hour, wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ids, _ := newTestService(t)
+			ctx := context.Background()
+			addTestMembers(t, service, "alice", map[string]string{"bob": MemberRoleMember, "carol": MemberRoleMember})
+			if err := service.SetPassword(ctx, "bob", "correct horse"); err != nil {
+				t.Fatal(err)
+			}
+
+			before := time.Now()
+			expires, err := service.Login(ctx, tt.member, tt.password, tt.ttl)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login(%q, %q) = %v, want %v", tt.member, tt.password, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if ids.member != "alice" || ids.session != "" {
+					t.Errorf("failed login changed the identity to %q with session %q", ids.member, ids.session)
+				}
+				return
+			}
+
+			ttl := tt.ttl
+			if ttl == 0 {
+				ttl = DefaultSessionTTL
+			}
+			if expires.Before(before.Add(ttl-time.Second)) || expires.After(time.Now().Add(ttl)) {
+				t.Errorf("session expires at %v, want %v after login", expires, ttl)
+			}
+			current, err := service.CurrentMember(ctx)
+			if err != nil || current != tt.member {
+				t.Errorf("current member = %q, %v, want %q", current, err, tt.member)
+			}
+		})
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		member   string
+		password string
+		wantErr  error
+	}{
+		{name: "own password", current: "bob", member: "bob", password: "correct horse"},
+		{name: "admin sets another member's", current: "alice", member: "bob", password: "correct horse"},
+		{name: "member sets another member's", current: "bob", member: "carol", password: "correct horse", wantErr: ErrPermissionDenied},
+		{name: "too short", current: "bob", member: "bob", password: "short", wantErr: ErrInvalidInput},
+		{name: "too long", current: "bob", member: "bob", password: string(make([]byte, 73)), wantErr: ErrInvalidInput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ids, _ := newTestService(t)
+			ctx := context.Background()
+			addTestMembers(t, service, "alice", map[string]string{"bob": MemberRoleMember, "carol": MemberRoleMember})
+			ids.member = tt.current
+
+			err := service.SetPassword(ctx, tt.member, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetPassword(%q) as %q = %v, want %v", tt.member, tt.current, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if _, err := service.Login(ctx, tt.member, tt.password, 0); err != nil {
+				t.Errorf("Login with the new password: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoginSession(t *testing.T) {
+	service, ids, db := newTestService(t)
+	ctx := context.Background()
+	addTestMembers(t, service, "alice", nil)
+	if err := service.SetPassword(ctx, "alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A member with a password needs to log in to act
+	if _, err := service.CurrentMember(ctx); !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("current member without login = %v, want login required", err)
+	}
+	if _, err := service.AddTask(ctx, NewTaskSchema{Name: "ship it"}); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("add task without login = %v, want login required", err)
+	}
+
+	if _, err := service.Login(ctx, "alice", "correct horse", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.AddTask(ctx, NewTaskSchema{Name: "ship it"}); err != nil {
+		t.Errorf("add task after login: %v", err)
+	}
+
+	// A session token from elsewhere isn't accepted
+	token := ids.session
+	ids.session = "not-a-session"
+	if _, err := service.CurrentMember(ctx); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("current member with another token = %v, want login required", err)
+	}
+	ids.session = token
+
+	if _, err := db.Exec("UPDATE member_sessions SET expires_at = datetime('now', '-1 minute')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.CurrentMember(ctx); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("current member after the session expired = %v, want login required", err)
+	}
+
+	if _, err := service.Login(ctx, "alice", "correct horse", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ids.session != "" {
+		t.Errorf("session after logout = %q, want none", ids.session)
+	}
+	if _, err := service.CurrentMember(ctx); !errors.Is(err, ErrLoginRequired) {
+		t.Errorf("current member after logout = %v, want login required", err)
+	}
+}
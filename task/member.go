@@ -0,0 +1,81 @@
+package task
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// OrphanedMember is a reference from a task to a member that isn't in the
+// members table, as reported by TaskService.FindOrphans
+type OrphanedMember struct {
+	TaskID int    `json:"task_id"`
+	Task   string `json:"task"`
+	Member string `json:"member"`
+	Role   string `json:"role"`
+	// Suggestion is the closest existing member name, if any is close
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// unknownMember returns an ErrUnknownMember error for name, suggesting the
+// closest member when there is one
+func unknownMember(name string, members []Member) error {
+	if suggestion := suggestMember(name, members); suggestion != "" {
+		return invalidInput("%w %q; did you mean %s?", ErrUnknownMember, name, suggestion)
+	}
+	return invalidInput("%w %q", ErrUnknownMember, name)
+}
+
+// suggestMember returns the member whose name is closest to name, ignoring
+// case, or "" when no name is close enough to be a likely typo
+func suggestMember(name string, members []Member) string {
+	best := ""
+	bestDistance := 0
+	for _, m := range members {
+		d := editDistance(strings.ToLower(name), strings.ToLower(m.Name))
+		if best == "" || d < bestDistance {
+			best, bestDistance = m.Name, d
+		}
+	}
+
+	// Allow one edit for short names and about one per three characters
+	// for longer ones
+	limit := utf8.RuneCountInString(name) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	if best == "" || bestDistance > limit {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// swaps of adjacent characters needed to turn a into b
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+
+	// rows[i][j] is the distance between s[:i] and t[:j]
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d := min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d = min(d, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = d
+		}
+	}
+	return rows[len(s)][len(t)]
+}
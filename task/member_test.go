@@ -0,0 +1,385 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// testIdentities is an IdentityStore for a single database that keeps the
+// current member and session in memory
+type testIdentities struct {
+	member  string
+	session string
+}
+
+func (i *testIdentities) Identity(database string) (string, error) { return i.member, nil }
+
+func (i *testIdentities) SetIdentity(database, member string) error {
+	i.member = member
+	return nil
+}
+
+func (i *testIdentities) Session(database string) (string, error) { return i.session, nil }
+
+func (i *testIdentities) SetSession(database, token string) error {
+	i.session = token
+	return nil
+}
+
+// newTestService returns a service on a new database in a temporary file,
+// with the local user's identity in ids
+func newTestService(t *testing.T) (TaskService, *testIdentities, *sql.DB) {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "task.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ids := &testIdentities{}
+	repo := NewTaskRepository(db, ids)
+	if err := repo.EnsureSchema(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewTaskService(repo), ids, db
+}
+
+// addTestMembers adds members with the given roles as admin, who becomes the
+// current member
+func addTestMembers(t *testing.T, service TaskService, admin string, roles map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+	if err := service.AddMember(ctx, admin); err != nil {
+		t.Fatal(err)
+	}
+	for name, role := range roles {
+		if err := service.AddMember(ctx, name); err != nil {
+			t.Fatal(err)
+		}
+		if err := service.SetMemberRole(ctx, name, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFirstMemberIsCurrentAdmin(t *testing.T) {
+	service, ids, _ := newTestService(t)
+	addTestMembers(t, service, "alice", nil)
+
+	member, err := service.CurrentMember(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member != "alice" || ids.member != "alice" {
+		t.Errorf("current member = %q, want alice", member)
+	}
+	members, err := service.ListMembers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].Role != MemberRoleAdmin {
+		t.Errorf("members = %+v, want alice as admin", members)
+	}
+}
+
+func TestSetCurrentMember(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		target  string
+		wantErr error
+	}{
+		{name: "viewer to admin", current: "carol", target: "alice", wantErr: ErrPermissionDenied},
+		{name: "viewer to member", current: "carol", target: "bob", wantErr: ErrPermissionDenied},
+		{name: "member to admin", current: "bob", target: "alice", wantErr: ErrPermissionDenied},
+		{name: "member to viewer", current: "bob", target: "carol", wantErr: ErrPermissionDenied},
+		{name: "viewer to themselves", current: "carol", target: "carol"},
+		{name: "admin to member", current: "alice", target: "bob"},
+		{name: "admin to other admin", current: "alice", target: "dave"},
+		{name: "new machine to member", current: "", target: "bob"},
+		{name: "new machine to admin", current: "", target: "alice", wantErr: ErrPermissionDenied},
+		{name: "renamed member to viewer", current: "karl", target: "carol"},
+		{name: "renamed member to admin", current: "karl", target: "alice", wantErr: ErrPermissionDenied},
+		{name: "viewer to new member", current: "carol", target: "erin", wantErr: ErrPermissionDenied},
+		{name: "admin to new member", current: "alice", target: "erin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ids, _ := newTestService(t)
+			addTestMembers(t, service, "alice", map[string]string{
+				"bob":   MemberRoleMember,
+				"carol": MemberRoleViewer,
+				"dave":  MemberRoleAdmin,
+			})
+			ids.member = tt.current
+
+			err := service.SetCurrentMember(context.Background(), tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetCurrentMember(%q) as %q = %v, want %v", tt.target, tt.current, err, tt.wantErr)
+			}
+			want := tt.target
+			if tt.wantErr != nil {
+				want = tt.current
+			}
+			if ids.member != want {
+				t.Errorf("current member = %q, want %q", ids.member, want)
+			}
+		})
+	}
+}
+
+func TestViewerCannotActAsAdmin(t *testing.T) {
+	service, ids, _ := newTestService(t)
+	ctx := context.Background()
+	addTestMembers(t, service, "alice", map[string]string{"carol": MemberRoleViewer})
+	added, err := service.AddTask(ctx, NewTaskSchema{Name: "ship it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids.member = "carol"
+	if err := service.SetCurrentMember(ctx, "alice"); !errors.Is(err, ErrPermissionDenied) {
+		t.Fatalf("switch-user alice as carol = %v, want permission denied", err)
+	}
+	if err := service.DeleteTask(ctx, added.Id); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("delete as carol = %v, want permission denied", err)
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	// Task 1 is owned by alice, an admin, and task 2 by bob, a member
+	tests := []struct {
+		name    string
+		actor   string
+		action  func(ctx context.Context, s TaskService) error
+		wantErr error
+	}{
+		{name: "viewer adds task", actor: "carol", action: addTask, wantErr: ErrPermissionDenied},
+		{name: "member adds task", actor: "bob", action: addTask},
+		{name: "viewer comments", actor: "carol", action: comment(1), wantErr: ErrPermissionDenied},
+		{name: "member comments", actor: "bob", action: comment(1)},
+		{name: "viewer updates task", actor: "carol", action: rename(1), wantErr: ErrPermissionDenied},
+		{name: "member updates other's task", actor: "bob", action: rename(1)},
+		{name: "viewer completes task", actor: "carol", action: complete(1), wantErr: ErrPermissionDenied},
+		{name: "member completes other's task", actor: "bob", action: complete(1)},
+		{name: "viewer deletes task", actor: "carol", action: deleteTask(1), wantErr: ErrPermissionDenied},
+		{name: "member deletes own task", actor: "bob", action: deleteTask(2)},
+		{name: "member deletes other's task", actor: "bob", action: deleteTask(1), wantErr: ErrPermissionDenied},
+		{name: "admin deletes other's task", actor: "alice", action: deleteTask(2)},
+		{name: "member assigns other's task", actor: "bob", action: assign(1, RoleAssignee, "bob")},
+		{name: "member gives away own task", actor: "bob", action: assign(2, RoleOwner, "alice")},
+		{name: "member takes other's task", actor: "bob", action: assign(1, RoleOwner, "bob"), wantErr: ErrPermissionDenied},
+		{name: "admin gives away other's task", actor: "alice", action: assign(2, RoleOwner, "alice")},
+		{name: "member adds member", actor: "bob", action: addMember("erin"), wantErr: ErrPermissionDenied},
+		{name: "admin adds member", actor: "alice", action: addMember("erin")},
+		{name: "member changes role", actor: "bob", action: setRole("bob", MemberRoleAdmin), wantErr: ErrPermissionDenied},
+		{name: "admin changes role", actor: "alice", action: setRole("carol", MemberRoleMember)},
+		{name: "last admin steps down", actor: "alice", action: setRole("alice", MemberRoleMember), wantErr: ErrInvalidInput},
+		{name: "no current member adds task", actor: "", action: addTask, wantErr: ErrNoCurrentMember},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ids, _ := newTestService(t)
+			ctx := context.Background()
+			addTestMembers(t, service, "alice", map[string]string{"bob": MemberRoleMember, "carol": MemberRoleViewer})
+			for _, owner := range []string{"alice", "bob"} {
+				ids.member = owner
+				if _, err := service.AddTask(ctx, NewTaskSchema{Name: "task of " + owner}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ids.member = tt.actor
+			if err := tt.action(ctx, service); !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s = %v, want %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func addTask(ctx context.Context, s TaskService) error {
+	_, err := s.AddTask(ctx, NewTaskSchema{Name: "ship it"})
+	return err
+}
+
+func comment(id int) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		return s.AddComment(ctx, id, "looks good")
+	}
+}
+
+func rename(id int) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		_, err := s.UpdateTask(ctx, UpdateTaskSchema{ID: id, Name: "ship it today"})
+		return err
+	}
+}
+
+func complete(id int) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		_, err := s.CompleteTask(ctx, id)
+		return err
+	}
+}
+
+func deleteTask(id int) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		return s.DeleteTask(ctx, id)
+	}
+}
+
+func assign(id int, role, member string) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		_, err := s.AssignTask(ctx, id, role, []string{member})
+		return err
+	}
+}
+
+func addMember(name string) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		return s.AddMember(ctx, name)
+	}
+}
+
+func setRole(name, role string) func(context.Context, TaskService) error {
+	return func(ctx context.Context, s TaskService) error {
+		return s.SetMemberRole(ctx, name, role)
+	}
+}
+
+func TestDeactivateMember(t *testing.T) {
+	tests := []struct {
+		name       string
+		actor      string
+		member     string
+		reassignTo string
+		// openTask gives the member an open task besides their done one
+		openTask bool
+		want     int
+		wantErr  error
+	}{
+		{name: "no open tasks", actor: "alice", member: "bob"},
+		{name: "open tasks reassigned", actor: "alice", member: "bob", reassignTo: "dave", openTask: true, want: 1},
+		{name: "open tasks reassigned to me", actor: "alice", member: "bob", reassignTo: "me", openTask: true, want: 1},
+		{name: "open tasks without reassign", actor: "alice", member: "bob", openTask: true, wantErr: ErrInvalidInput},
+		{name: "reassign to viewer", actor: "alice", member: "bob", reassignTo: "carol", openTask: true, wantErr: ErrInvalidInput},
+		{name: "reassign to themselves", actor: "alice", member: "bob", reassignTo: "bob", wantErr: ErrInvalidInput},
+		{name: "reassign to unknown member", actor: "alice", member: "bob", reassignTo: "erin", wantErr: ErrUnknownMember},
+		{name: "last admin", actor: "alice", member: "alice", wantErr: ErrInvalidInput},
+		{name: "unknown member", actor: "alice", member: "erin", wantErr: ErrUnknownMember},
+		{name: "by a member", actor: "dave", member: "bob", wantErr: ErrPermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, ids, _ := newTestService(t)
+			ctx := context.Background()
+			addTestMembers(t, service, "alice", map[string]string{
+				"bob":   MemberRoleMember,
+				"carol": MemberRoleViewer,
+				"dave":  MemberRoleMember,
+			})
+			ids.member = "bob"
+			done, err := service.AddTask(ctx, NewTaskSchema{Name: "done task"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := service.CompleteTask(ctx, done.Id); err != nil {
+				t.Fatal(err)
+			}
+			var open *Task
+			if tt.openTask {
+				if open, err = service.AddTask(ctx, NewTaskSchema{Name: "open task"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ids.member = tt.actor
+			got, err := service.DeactivateMember(ctx, tt.member, tt.reassignTo)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeactivateMember(%q, %q) = %v, want %v", tt.member, tt.reassignTo, err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DeactivateMember(%q, %q) reassigned %d tasks, want %d", tt.member, tt.reassignTo, got, tt.want)
+			}
+
+			// Done tasks keep their owner, open ones move
+			d, err := service.GetTask(ctx, done.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.Owner != tt.member {
+				t.Errorf("owner of the done task = %q, want %q", d.Owner, tt.member)
+			}
+			if open != nil {
+				want := tt.reassignTo
+				if want == "me" {
+					want = tt.actor
+				}
+				d, err := service.GetTask(ctx, open.Id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d.Owner != want {
+					t.Errorf("owner of the open task = %q, want %q", d.Owner, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeactivatedMember(t *testing.T) {
+	service, ids, _ := newTestService(t)
+	ctx := context.Background()
+	addTestMembers(t, service, "alice", map[string]string{"bob": MemberRoleMember})
+	added, err := service.AddTask(ctx, NewTaskSchema{Name: "ship it"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.DeactivateMember(ctx, "bob", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := service.ListMembers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range members {
+		if m.Name == "bob" && m.Active() {
+			t.Errorf("bob is still active after deactivating them")
+		}
+	}
+	if _, err := service.DeactivateMember(ctx, "bob", ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("deactivating bob again = %v, want invalid input", err)
+	}
+	if _, err := service.AssignTask(ctx, added.Id, RoleAssignee, []string{"bob"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("assigning bob = %v, want invalid input", err)
+	}
+	ids.member = "bob"
+	if _, err := service.AddTask(ctx, NewTaskSchema{Name: "sneak in"}); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("adding a task as bob = %v, want permission denied", err)
+	}
+
+	ids.member = "alice"
+	if err := service.ActivateMember(ctx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ActivateMember(ctx, "bob"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("activating bob again = %v, want invalid input", err)
+	}
+	if _, err := service.AssignTask(ctx, added.Id, RoleAssignee, []string{"bob"}); err != nil {
+		t.Errorf("assigning bob after activating them: %v", err)
+	}
+	ids.member = "bob"
+	if _, err := service.AddTask(ctx, NewTaskSchema{Name: "back at it"}); err != nil {
+		t.Errorf("adding a task as bob after activating them: %v", err)
+	}
+}
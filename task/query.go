@@ -0,0 +1,100 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField is a single sort key. Desc reverses the order.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// TaskQuery describes which tasks to load and in which order
+type TaskQuery struct {
+	Filter TaskFilter
+	Sort   []SortField
+	Limit  int
+	Offset int
+}
+
+// ListOptions selects the tasks returned by TaskService.ListTasks
+type ListOptions struct {
+	Completed bool
+	All       bool
+	Filter    string
+	Sort      string
+	Limit     int
+	Offset    int
+	// Assignee restricts the list to tasks with an assignee of that name,
+	// or "me"
+	Assignee string
+}
+
+// ListColumns are the columns that can be selected with --columns
+var ListColumns = []string{"id", "name", "status", "priority", "owner", "assignees", "created", "due"}
+
+// columnAliases maps other accepted names of columns and sort fields to the
+// names in ListColumns. collaborator is the old name of assignees, as in
+// filters.
+var columnAliases = map[string]string{"collaborator": "assignees"}
+
+// canonicalColumn returns the ListColumns name of a column or sort field
+func canonicalColumn(name string) string {
+	if column, ok := columnAliases[name]; ok {
+		return column
+	}
+	return name
+}
+
+// ParseSort parses a comma separated list of sort fields such as
+// "created,-priority". A leading "-" sorts that field in descending order.
+func ParseSort(spec string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		field := SortField{Field: strings.ToLower(part)}
+		if strings.HasPrefix(part, "-") {
+			field = SortField{Field: strings.ToLower(part[1:]), Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			field.Field = strings.ToLower(part[1:])
+		}
+		field.Field = canonicalColumn(field.Field)
+
+		if !containsString(ListColumns, field.Field) {
+			return nil, fmt.Errorf("unknown sort field %q", field.Field)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// ParseColumns parses a comma separated list of output columns
+func ParseColumns(spec string) ([]string, error) {
+	var columns []string
+	for _, part := range strings.Split(spec, ",") {
+		column := canonicalColumn(strings.ToLower(strings.TrimSpace(part)))
+		if column == "" {
+			continue
+		}
+		if !containsString(ListColumns, column) {
+			return nil, fmt.Errorf("unknown column %q: expected one of %s", column, strings.Join(ListColumns, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
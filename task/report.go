@@ -0,0 +1,137 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// PriorityCounts counts tasks by priority
+type PriorityCounts struct {
+	High   int `json:"high"`
+	Medium int `json:"medium"`
+	Low    int `json:"low"`
+	None   int `json:"none"`
+}
+
+// add counts a task with the given priority
+func (c *PriorityCounts) add(priority int) {
+	switch priority {
+	case PriorityHigh:
+		c.High++
+	case PriorityMedium:
+		c.Medium++
+	case PriorityLow:
+		c.Low++
+	default:
+		c.None++
+	}
+}
+
+// StatusCount is the number of tasks with a status
+type StatusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+func (c StatusCount) String() string {
+	return fmt.Sprintf("%s: %d", c.Status, c.Count)
+}
+
+// MemberWorkload is a member's share of the work, as shown by task report
+// workload. All counts except ByStatus are of open tasks, i.e. tasks that
+// aren't done.
+type MemberWorkload struct {
+	Member string `json:"member"`
+	Open   int    `json:"open"`
+	// Owned and Collaborating split Open into the tasks the member owns and
+	// the ones they have another role on
+	Owned         int `json:"owned"`
+	Collaborating int `json:"collaborating"`
+	Overdue       int `json:"overdue"`
+	// AverageAgeDays is the average number of days since the open tasks were
+	// created
+	AverageAgeDays float64        `json:"average_age_days"`
+	ByPriority     PriorityCounts `json:"by_priority"`
+	// ByStatus counts all the member's tasks by status, in workflow order
+	ByStatus []StatusCount `json:"by_status"`
+}
+
+// buildWorkload counts the tasks of each member. Every name in members gets
+// a row, as does anyone else assigned to an open task. Rows are sorted by the
+// number of open tasks, busiest first.
+func buildWorkload(tasks []Task, members []string, statuses []string, now time.Time) []MemberWorkload {
+	rows := map[string]*MemberWorkload{}
+	ages := map[string]float64{}
+	row := func(member string) *MemberWorkload {
+		if w, ok := rows[member]; ok {
+			return w
+		}
+		w := &MemberWorkload{Member: member, ByStatus: make([]StatusCount, len(statuses))}
+		for i, status := range statuses {
+			w.ByStatus[i].Status = status
+		}
+		rows[member] = w
+		return w
+	}
+	for _, member := range members {
+		row(member)
+	}
+
+	for _, t := range tasks {
+		for _, a := range t.Assignees {
+			if _, ok := rows[a.Member]; !ok && t.Status == "done" {
+				continue
+			}
+			w := row(a.Member)
+			for i := range w.ByStatus {
+				if w.ByStatus[i].Status == t.Status {
+					w.ByStatus[i].Count++
+				}
+			}
+			if t.Status == "done" {
+				continue
+			}
+
+			w.Open++
+			if a.Role == RoleOwner {
+				w.Owned++
+			} else {
+				w.Collaborating++
+			}
+			if t.Overdue(now) {
+				w.Overdue++
+			}
+			w.ByPriority.add(t.Priority)
+			if created, ok := ParseTimestamp(t.CreatedAt); ok {
+				ages[a.Member] += now.Sub(created).Hours() / 24
+			}
+		}
+	}
+
+	workload := make([]MemberWorkload, 0, len(rows))
+	for member, w := range rows {
+		if w.Open > 0 {
+			w.AverageAgeDays = math.Round(ages[member]/float64(w.Open)*10) / 10
+		}
+		workload = append(workload, *w)
+	}
+	sort.Slice(workload, func(i, j int) bool {
+		if workload[i].Open != workload[j].Open {
+			return workload[i].Open > workload[j].Open
+		}
+		return workload[i].Member < workload[j].Member
+	})
+	return workload
+}
+
+// ParseTimestamp parses a timestamp as returned for the TIMESTAMP columns
+func ParseTimestamp(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,301 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Markers wrapped around matched terms in search results. They are replaced
+// with terminal formatting when the results are printed.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// snippetRadius is the number of characters kept on each side of a match
+const snippetRadius = 30
+
+// ensureSearchIndex creates the FTS5 index over task names, descriptions and
+// comments, and the triggers that keep it in sync with the tasks table. It
+// returns an error when the database has no FTS5 support.
+func (r *TaskRepositoryImpl) ensureSearchIndex(ctx context.Context) error {
+	var tableExists int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='tasks_fts'").Scan(&tableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if tasks_fts table exists: %v", err)
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS tasks_fts USING fts5(name, description, comments)`,
+
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_insert AFTER INSERT ON tasks BEGIN
+			INSERT INTO tasks_fts (rowid, name, description, comments) VALUES (new.id, new.name, new.description, '');
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_update AFTER UPDATE OF name, description ON tasks BEGIN
+			UPDATE tasks_fts SET name = new.name, description = new.description WHERE rowid = new.id;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS tasks_fts_delete AFTER DELETE ON tasks BEGIN
+			DELETE FROM tasks_fts WHERE rowid = old.id;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS task_comments_fts_insert AFTER INSERT ON task_comments BEGIN
+			UPDATE tasks_fts
+			SET comments = (SELECT IFNULL(group_concat(body, ' '), '') FROM task_comments WHERE task_id = new.task_id)
+			WHERE rowid = new.task_id;
+		END`,
+
+		`CREATE TRIGGER IF NOT EXISTS task_comments_fts_delete AFTER DELETE ON task_comments BEGIN
+			UPDATE tasks_fts
+			SET comments = (SELECT IFNULL(group_concat(body, ' '), '') FROM task_comments WHERE task_id = old.task_id)
+			WHERE rowid = old.task_id;
+		END`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("error executing SQL statement: %v\nStatement: %s", err, stmt)
+		}
+	}
+
+	// Index the tasks that existed before the index was created
+	if tableExists == 0 {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO tasks_fts (rowid, name, description, comments)
+			SELECT t.id, t.name, t.description,
+				(SELECT IFNULL(group_concat(c.body, ' '), '') FROM task_comments c WHERE c.task_id = t.id)
+			FROM tasks t
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to populate search index: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// SearchTasks returns the tasks matching every term of the query, best match
+// first. Matched terms are wrapped in HighlightStart and HighlightEnd.
+func (r *TaskRepositoryImpl) SearchTasks(ctx context.Context, query string) ([]SearchResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	if r.fts {
+		return r.searchFTS(ctx, terms)
+	}
+	return r.searchLike(ctx, terms)
+}
+
+// searchFTS searches the FTS5 index, ranking with bm25. Name matches weigh
+// more than description matches, which weigh more than comment matches.
+func (r *TaskRepositoryImpl) searchFTS(ctx context.Context, terms []string) ([]SearchResult, error) {
+	query := `
+		SELECT ` + taskColumns + `,
+			highlight(tasks_fts, 0, ?, ?),
+			snippet(tasks_fts, 1, ?, ?, '…', 12),
+			snippet(tasks_fts, 2, ?, ?, '…', 12),
+			bm25(tasks_fts, 10.0, 5.0, 1.0) AS score
+		FROM tasks_fts
+		JOIN tasks t ON t.id = tasks_fts.rowid
+		JOIN status s ON t.status = s.id
+		WHERE tasks_fts MATCH ?
+		ORDER BY score, t.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		HighlightStart, HighlightEnd,
+		HighlightStart, HighlightEnd,
+		HighlightStart, HighlightEnd,
+		ftsQuery(terms),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to search tasks: %v", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var result SearchResult
+		var descSnippet, commentSnippet string
+		var err error
+		result.Task, err = scanTask(rows, &result.Name, &descSnippet, &commentSnippet, &result.Score)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to scan result: %v", err)
+		}
+
+		// bm25 scores are negative, lower is better
+		result.Score = -result.Score
+
+		if strings.Contains(descSnippet, HighlightStart) {
+			result.Snippet = descSnippet
+		} else if strings.Contains(commentSnippet, HighlightStart) {
+			result.Snippet = commentSnippet
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// ftsQuery turns search terms into an FTS5 query. Each term is quoted so
+// punctuation in the input can't be read as query syntax. A trailing * keeps
+// its meaning as a prefix search.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		prefix := ""
+		if strings.HasSuffix(term, "*") && len(term) > 1 {
+			term = strings.TrimSuffix(term, "*")
+			prefix = "*"
+		}
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"` + prefix
+	}
+	return strings.Join(quoted, " ")
+}
+
+// searchLike is the fallback for databases without FTS5. Every term must
+// appear in the name, description or a comment; results are ranked by where
+// the terms matched.
+func (r *TaskRepositoryImpl) searchLike(ctx context.Context, terms []string) ([]SearchResult, error) {
+	var clauses []string
+	var args []interface{}
+	for _, term := range terms {
+		pattern := "%" + escapeLike(strings.TrimSuffix(term, "*")) + "%"
+		clauses = append(clauses, `(t.name LIKE ? ESCAPE '\' OR t.description LIKE ? ESCAPE '\'
+			OR EXISTS (SELECT 1 FROM task_comments c WHERE c.task_id = t.id AND c.body LIKE ? ESCAPE '\'))`)
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks t
+		JOIN status s ON t.status = s.id
+		WHERE ` + strings.Join(clauses, " AND ")
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to search tasks: %v", err)
+	}
+
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Failed to scan result: %v", err)
+		}
+		tasks = append(tasks, task)
+	}
+	rows.Close()
+
+	var results []SearchResult
+	for _, task := range tasks {
+		comments, err := r.GetComments(ctx, task.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		result := SearchResult{Task: task, Name: highlightTerms(task.Name, terms)}
+		for _, term := range terms {
+			term = strings.ToLower(strings.TrimSuffix(term, "*"))
+			if strings.Contains(strings.ToLower(task.Name), term) {
+				result.Score += 10
+			}
+			if strings.Contains(strings.ToLower(task.Description), term) {
+				result.Score += 5
+				if result.Snippet == "" {
+					result.Snippet = highlightTerms(snippetAround(task.Description, term), terms)
+				}
+			}
+			for _, comment := range comments {
+				if strings.Contains(strings.ToLower(comment.Body), term) {
+					result.Score++
+					if result.Snippet == "" {
+						result.Snippet = highlightTerms(snippetAround(comment.Body, term), terms)
+					}
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	return results, nil
+}
+
+// highlightTerms wraps case-insensitive occurrences of the terms in highlight markers
+func highlightTerms(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		term = strings.ToLower(strings.TrimSuffix(term, "*"))
+		if term == "" || len(lower) != len(text) {
+			continue
+		}
+		for start := 0; ; {
+			idx := strings.Index(lower[start:], term)
+			if idx < 0 {
+				break
+			}
+			for i := start + idx; i < start+idx+len(term); i++ {
+				marked[i] = true
+			}
+			start += idx + len(term)
+		}
+	}
+
+	var b strings.Builder
+	inMatch := false
+	for i := 0; i < len(text); i++ {
+		if marked[i] != inMatch {
+			if marked[i] {
+				b.WriteString(HighlightStart)
+			} else {
+				b.WriteString(HighlightEnd)
+			}
+			inMatch = marked[i]
+		}
+		b.WriteByte(text[i])
+	}
+	if inMatch {
+		b.WriteString(HighlightEnd)
+	}
+	return b.String()
+}
+
+// snippetAround returns the part of text surrounding the first occurrence of term
+func snippetAround(text, term string) string {
+	idx := strings.Index(strings.ToLower(text), term)
+	if idx < 0 || len(text) <= 2*snippetRadius+len(term) {
+		return text
+	}
+
+	start := idx - snippetRadius
+	end := idx + len(term) + snippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	}
+
+	// Don't cut multi-byte characters in half
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	return prefix + text[start:end] + suffix
+}
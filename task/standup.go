@@ -0,0 +1,154 @@
+package task
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultDigestSince is the period task digest covers unless told otherwise
+const DefaultDigestSince = "7d"
+
+// Standup is what a member did since the last working day and is working on,
+// as shown by task standup. Every task is listed with the event that gave it
+// its current status.
+type Standup struct {
+	Member string `json:"member"`
+	// Since is the last working day as YYYY-MM-DD. Completed lists the tasks
+	// completed since its start.
+	Since      string      `json:"since"`
+	Completed  []TaskEvent `json:"completed"`
+	InProgress []TaskEvent `json:"in_progress"`
+	Blocked    []TaskEvent `json:"blocked"`
+}
+
+// Digest is the activity of the team in a period, as shown by task digest.
+// Created, Started and Completed list the events of the period, the latest
+// one for tasks started or completed more than once. Blocked lists the tasks
+// that are blocked now with the event that blocked them, which may be older.
+type Digest struct {
+	// Since and Until are the first and last day covered, as YYYY-MM-DD
+	Since     string           `json:"since"`
+	Until     string           `json:"until"`
+	Created   []TaskEvent      `json:"created"`
+	Started   []TaskEvent      `json:"started"`
+	Completed []TaskEvent      `json:"completed"`
+	Blocked   []TaskEvent      `json:"blocked"`
+	Members   []MemberActivity `json:"members"`
+}
+
+// MemberActivity counts the changes a member made in the period of a Digest
+type MemberActivity struct {
+	Member    string `json:"member"`
+	Created   int    `json:"created"`
+	Started   int    `json:"started"`
+	Completed int    `json:"completed"`
+	Blocked   int    `json:"blocked"`
+}
+
+// lastWorkingDay returns midnight of the last weekday before t
+func lastWorkingDay(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day()-1, 0, 0, 0, 0, t.Location())
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// buildStandup sorts the current status events of a member's tasks into the
+// sections of their standup
+func buildStandup(member string, current []TaskEvent, since time.Time) *Standup {
+	standup := &Standup{
+		Member:     member,
+		Since:      since.Format(time.DateOnly),
+		Completed:  []TaskEvent{},
+		InProgress: []TaskEvent{},
+		Blocked:    []TaskEvent{},
+	}
+	for _, e := range current {
+		switch e.Value {
+		case StatusDone:
+			if at, ok := ParseTimestamp(e.CreatedAt); ok && !at.Before(since) {
+				standup.Completed = append(standup.Completed, e)
+			}
+		case StatusInProgress:
+			standup.InProgress = append(standup.InProgress, e)
+		case StatusBlocked:
+			standup.Blocked = append(standup.Blocked, e)
+		}
+	}
+	return standup
+}
+
+// buildDigest sorts the events of a period into the sections of a digest and
+// counts them per member. current are the current status events of all tasks.
+func buildDigest(events, current []TaskEvent, since, now time.Time) *Digest {
+	digest := &Digest{
+		Since:   since.Format(time.DateOnly),
+		Until:   now.Format(time.DateOnly),
+		Created: []TaskEvent{},
+		Blocked: []TaskEvent{},
+		Members: []MemberActivity{},
+	}
+
+	// Events recorded before the history was kept may have no actor; they
+	// are listed but not counted
+	activity := map[string]*MemberActivity{}
+	count := func(e TaskEvent) *MemberActivity {
+		if e.Actor == "" {
+			return &MemberActivity{}
+		}
+		a, ok := activity[e.Actor]
+		if !ok {
+			a = &MemberActivity{Member: e.Actor}
+			activity[e.Actor] = a
+		}
+		return a
+	}
+	var started, completed []TaskEvent
+	for _, e := range events {
+		switch {
+		case e.Type == EventCreated:
+			digest.Created = append(digest.Created, e)
+			count(e).Created++
+		case e.Type == EventStatus && e.Value == StatusInProgress:
+			started = append(started, e)
+			count(e).Started++
+		case e.Type == EventStatus && e.Value == StatusDone:
+			completed = append(completed, e)
+			count(e).Completed++
+		case e.Type == EventStatus && e.Value == StatusBlocked:
+			count(e).Blocked++
+		}
+	}
+	digest.Started = latestPerTask(started)
+	digest.Completed = latestPerTask(completed)
+
+	for _, e := range current {
+		if e.Value == StatusBlocked {
+			digest.Blocked = append(digest.Blocked, e)
+		}
+	}
+
+	for _, a := range activity {
+		digest.Members = append(digest.Members, *a)
+	}
+	sort.Slice(digest.Members, func(i, j int) bool {
+		return digest.Members[i].Member < digest.Members[j].Member
+	})
+	return digest
+}
+
+// latestPerTask keeps the last of the events of each task, in their order
+func latestPerTask(events []TaskEvent) []TaskEvent {
+	last := map[int]int{}
+	for i, e := range events {
+		last[e.TaskID] = i
+	}
+	latest := []TaskEvent{}
+	for i, e := range events {
+		if last[e.TaskID] == i {
+			latest = append(latest, e)
+		}
+	}
+	return latest
+}
@@ -0,0 +1,98 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLastWorkingDay(t *testing.T) {
+	tests := []struct {
+		now  string
+		want string
+	}{
+		{now: "2026-10-20 09:30:00", want: "2026-10-19"}, // Tuesday
+		{now: "2026-10-19 09:30:00", want: "2026-10-16"}, // Monday
+		{now: "2026-10-19 00:00:00", want: "2026-10-16"},
+		{now: "2026-10-17 12:00:00", want: "2026-10-16"}, // Saturday
+		{now: "2026-10-18 23:59:59", want: "2026-10-16"}, // Sunday
+		{now: "2026-06-01 09:00:00", want: "2026-05-29"}, // Monday after a month end
+		{now: "2026-01-01 09:00:00", want: "2025-12-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.now, func(t *testing.T) {
+			got := lastWorkingDay(statsTime(tt.now))
+			if got.Format(time.DateTime) != tt.want+" 00:00:00" {
+				t.Errorf("lastWorkingDay(%s) = %v, want midnight of %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStandup(t *testing.T) {
+	since := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	event := func(id int, status, at string) TaskEvent {
+		return TaskEvent{ID: id, TaskID: id, Type: EventStatus, Value: status, Actor: "bob", CreatedAt: at}
+	}
+	current := []TaskEvent{
+		event(1, StatusDone, "2026-10-15 23:59:59"),
+		event(2, StatusDone, "2026-10-16 00:00:00"),
+		event(3, StatusDone, "2026-10-19T08:00:00Z"),
+		event(4, StatusInProgress, "2026-09-01 10:00:00"),
+		event(5, StatusBlocked, "2026-10-18 10:00:00"),
+		event(6, StatusPending, "2026-10-18 11:00:00"),
+		event(7, StatusDone, "yesterday"),
+	}
+
+	want := &Standup{
+		Member:     "bob",
+		Since:      "2026-10-16",
+		Completed:  []TaskEvent{current[1], current[2]},
+		InProgress: []TaskEvent{current[3]},
+		Blocked:    []TaskEvent{current[4]},
+	}
+	if got := buildStandup("bob", current, since); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildStandup() = %+v, want %+v", got, want)
+	}
+
+	// Sections are empty lists rather than null in JSON output
+	empty := buildStandup("bob", nil, since)
+	if empty.Completed == nil || empty.InProgress == nil || empty.Blocked == nil {
+		t.Errorf("buildStandup() without events = %+v, want empty sections", empty)
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	since := time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)
+	events := []TaskEvent{
+		{TaskID: 1, Type: EventCreated, Actor: "alice", CreatedAt: "2026-10-13 09:00:00"},
+		{TaskID: 1, Type: EventStatus, Value: StatusInProgress, Actor: "bob", CreatedAt: "2026-10-13 10:00:00"},
+		{TaskID: 1, Type: EventStatus, Value: StatusBlocked, Actor: "bob", CreatedAt: "2026-10-14 10:00:00"},
+		{TaskID: 1, Type: EventStatus, Value: StatusInProgress, Actor: "bob", CreatedAt: "2026-10-15 10:00:00"},
+		{TaskID: 2, Type: EventStatus, Value: StatusDone, Actor: "alice", CreatedAt: "2026-10-16 10:00:00"},
+		// Recorded before the history was kept
+		{TaskID: 3, Type: EventCreated, CreatedAt: "2026-10-16 11:00:00"},
+	}
+	current := []TaskEvent{
+		events[3],
+		events[4],
+		{TaskID: 4, Type: EventStatus, Value: StatusBlocked, Actor: "carol", CreatedAt: "2026-10-01 10:00:00"},
+	}
+
+	want := &Digest{
+		Since:     "2026-10-13",
+		Until:     "2026-10-19",
+		Created:   []TaskEvent{events[0], events[5]},
+		Started:   []TaskEvent{events[3]},
+		Completed: []TaskEvent{events[4]},
+		Blocked:   []TaskEvent{current[2]},
+		Members: []MemberActivity{
+			{Member: "alice", Created: 1, Completed: 1},
+			{Member: "bob", Started: 2, Blocked: 1},
+		},
+	}
+	if got := buildDigest(events, current, since, statsNow); !reflect.DeepEqual(got, want) {
+		t.Errorf("buildDigest() = %+v, want %+v", got, want)
+	}
+}
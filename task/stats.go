@@ -0,0 +1,174 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stats intervals
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// DefaultStatsSince is how far back task stats looks unless told otherwise
+const DefaultStatsSince = "30d"
+
+// StatsOptions selects the tasks and periods of task stats. Since is a number
+// of days or weeks back from today such as 30d or 4w, or a YYYY-MM-DD date.
+// Member limits the stats to the tasks a member is assigned to and may be
+// "me". Interval is IntervalDay or IntervalWeek.
+type StatsOptions struct {
+	Since    string
+	Member   string
+	Interval string
+}
+
+// Stats is the throughput of a team or member, as shown by task stats
+type Stats struct {
+	// Since and Until are the first and last day covered, as YYYY-MM-DD
+	Since    string `json:"since"`
+	Until    string `json:"until"`
+	Member   string `json:"member,omitempty"`
+	Interval string `json:"interval"`
+	// Created and Completed count the tasks created and completed in the
+	// covered days
+	Created   int `json:"created"`
+	Completed int `json:"completed"`
+	// LeadTime is the time from creation to completion and CycleTime the
+	// time from being started to completion, of the tasks completed in the
+	// covered days. Tasks that were never in progress have no cycle time.
+	LeadTime  DurationStats `json:"lead_time"`
+	CycleTime DurationStats `json:"cycle_time"`
+	Series    []StatsPoint  `json:"series"`
+}
+
+// StatsPoint is one day or week of Stats
+type StatsPoint struct {
+	// Period is the first day of the period as YYYY-MM-DD
+	Period    string `json:"period"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	// Open is the number of open tasks at the end of the period, which makes
+	// the burndown
+	Open int `json:"open"`
+}
+
+// DurationStats summarizes durations in hours. Percentiles use the
+// nearest-rank method.
+type DurationStats struct {
+	Count       int     `json:"count"`
+	MedianHours float64 `json:"median_hours"`
+	P90Hours    float64 `json:"p90_hours"`
+}
+
+// TaskTimeline is when a task was created, first started and completed.
+// StartedAt is zero for tasks that were never in progress, and CompletedAt
+// for open tasks.
+type TaskTimeline struct {
+	ID          int
+	CreatedAt   time.Time
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// parseSince parses StatsOptions.Since into midnight of the first day covered
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if day, err := time.ParseInLocation(time.DateOnly, value, now.Location()); err == nil {
+		if day.After(today) {
+			return time.Time{}, fmt.Errorf("since date %s is in the future", value)
+		}
+		return day, nil
+	}
+
+	if len(value) > 1 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err == nil && n > 0 {
+			switch value[len(value)-1] {
+			case 'd':
+				return today.AddDate(0, 0, -(n - 1)), nil
+			case 'w':
+				return today.AddDate(0, 0, -(7*n - 1)), nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid since %q: expected a number of days or weeks such as 30d or 4w, or YYYY-MM-DD", value)
+}
+
+// buildStats computes the stats of the given tasks from since until now.
+// Weekly periods start on Mondays, so since is moved back to a Monday.
+func buildStats(tasks []TaskTimeline, since, now time.Time, interval string) *Stats {
+	next := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	if interval == IntervalWeek {
+		since = startOfWeek(since)
+		next = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	}
+
+	stats := &Stats{
+		Since:    since.Format(time.DateOnly),
+		Until:    now.Format(time.DateOnly),
+		Interval: interval,
+		Series:   []StatsPoint{},
+	}
+	for start := since; !start.After(now); start = next(start) {
+		end := next(start)
+		point := StatsPoint{Period: start.Format(time.DateOnly)}
+		for _, t := range tasks {
+			if within(t.CreatedAt, start, end) {
+				point.Created++
+			}
+			if within(t.CompletedAt, start, end) {
+				point.Completed++
+			}
+			if t.CreatedAt.Before(end) && (t.CompletedAt.IsZero() || !t.CompletedAt.Before(end)) {
+				point.Open++
+			}
+		}
+		stats.Created += point.Created
+		stats.Completed += point.Completed
+		stats.Series = append(stats.Series, point)
+	}
+
+	var lead, cycle []float64
+	for _, t := range tasks {
+		if t.CompletedAt.IsZero() || t.CompletedAt.Before(since) {
+			continue
+		}
+		lead = append(lead, t.CompletedAt.Sub(t.CreatedAt).Hours())
+		if !t.StartedAt.IsZero() && !t.StartedAt.After(t.CompletedAt) {
+			cycle = append(cycle, t.CompletedAt.Sub(t.StartedAt).Hours())
+		}
+	}
+	stats.LeadTime = summarizeHours(lead)
+	stats.CycleTime = summarizeHours(cycle)
+	return stats
+}
+
+// within reports whether t is set and in [start, end)
+func within(t, start, end time.Time) bool {
+	return !t.IsZero() && !t.Before(start) && t.Before(end)
+}
+
+// summarizeHours returns the count, median and 90th percentile of hours,
+// rounded to a tenth of an hour
+func summarizeHours(hours []float64) DurationStats {
+	if len(hours) == 0 {
+		return DurationStats{}
+	}
+	sort.Float64s(hours)
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p * float64(len(hours))))
+		return math.Round(hours[max(rank-1, 0)]*10) / 10
+	}
+	return DurationStats{
+		Count:       len(hours),
+		MedianHours: percentile(0.5),
+		P90Hours:    percentile(0.9),
+	}
+}
@@ -0,0 +1,144 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// statsNow is a Monday afternoon
+var statsNow = time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)
+
+func statsTime(value string) time.Time {
+	t, err := time.ParseInLocation(time.DateTime, value, time.UTC)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSummarizeHours(t *testing.T) {
+	tests := []struct {
+		name  string
+		hours []float64
+		want  DurationStats
+	}{
+		{name: "none", want: DurationStats{}},
+		{name: "one", hours: []float64{5}, want: DurationStats{Count: 1, MedianHours: 5, P90Hours: 5}},
+		{name: "even count takes the lower median", hours: []float64{1, 2, 3, 4}, want: DurationStats{Count: 4, MedianHours: 2, P90Hours: 4}},
+		{name: "unsorted", hours: []float64{3, 1, 2}, want: DurationStats{Count: 3, MedianHours: 2, P90Hours: 3}},
+		{
+			name:  "ten",
+			hours: []float64{10, 9, 8, 7, 6, 5, 4, 3, 2, 1},
+			want:  DurationStats{Count: 10, MedianHours: 5, P90Hours: 9},
+		},
+		{name: "rounded to a tenth", hours: []float64{1.25, 0.04}, want: DurationStats{Count: 2, MedianHours: 0, P90Hours: 1.3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := summarizeHours(tt.hours); got != tt.want {
+				t.Errorf("summarizeHours(%v) = %+v, want %+v", tt.hours, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "1d", want: "2026-10-19"},
+		{value: "30d", want: "2026-09-20"},
+		{value: " 7D ", want: "2026-10-13"},
+		{value: "1w", want: "2026-10-13"},
+		{value: "4w", want: "2026-09-22"},
+		{value: "2026-10-01", want: "2026-10-01"},
+		{value: "2026-10-19", want: "2026-10-19"},
+		{value: "2026-10-20", wantErr: true},
+		{value: "2026-13-01", wantErr: true},
+		{value: "0d", wantErr: true},
+		{value: "-3d", wantErr: true},
+		{value: "3m", wantErr: true},
+		{value: "30", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, statsNow)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseSince(%q) = %v, want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSince(%q): %v", tt.value, err)
+			}
+			if got.Format(time.DateTime) != tt.want+" 00:00:00" {
+				t.Errorf("parseSince(%q) = %v, want midnight of %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildStats(t *testing.T) {
+	tasks := []TaskTimeline{
+		// Lead time 168h, cycle time 24h
+		{ID: 1, CreatedAt: statsTime("2026-10-10 09:00:00"), StartedAt: statsTime("2026-10-16 09:00:00"), CompletedAt: statsTime("2026-10-17 09:00:00")},
+		// Lead time 24h, never started
+		{ID: 2, CreatedAt: statsTime("2026-10-17 10:00:00"), CompletedAt: statsTime("2026-10-18 10:00:00")},
+		{ID: 3, CreatedAt: statsTime("2026-10-18 12:00:00"), StartedAt: statsTime("2026-10-19 08:00:00")},
+		// Completed before the stats start
+		{ID: 4, CreatedAt: statsTime("2026-10-01 09:00:00"), CompletedAt: statsTime("2026-10-05 09:00:00")},
+		{ID: 5, CreatedAt: statsTime("2026-10-19 09:00:00")},
+	}
+	since := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		interval string
+		want     *Stats
+	}{
+		{
+			interval: IntervalDay,
+			want: &Stats{
+				Since: "2026-10-17", Until: "2026-10-19", Interval: IntervalDay,
+				Created: 3, Completed: 2,
+				LeadTime:  DurationStats{Count: 2, MedianHours: 24, P90Hours: 168},
+				CycleTime: DurationStats{Count: 1, MedianHours: 24, P90Hours: 24},
+				Series: []StatsPoint{
+					{Period: "2026-10-17", Created: 1, Completed: 1, Open: 1},
+					{Period: "2026-10-18", Created: 1, Completed: 1, Open: 1},
+					{Period: "2026-10-19", Created: 1, Completed: 0, Open: 2},
+				},
+			},
+		},
+		{
+			// Weeks start on the Monday before since
+			interval: IntervalWeek,
+			want: &Stats{
+				Since: "2026-10-12", Until: "2026-10-19", Interval: IntervalWeek,
+				Created: 3, Completed: 2,
+				LeadTime:  DurationStats{Count: 2, MedianHours: 24, P90Hours: 168},
+				CycleTime: DurationStats{Count: 1, MedianHours: 24, P90Hours: 24},
+				Series: []StatsPoint{
+					{Period: "2026-10-12", Created: 2, Completed: 2, Open: 1},
+					{Period: "2026-10-19", Created: 1, Completed: 0, Open: 2},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			got := buildStats(tasks, since, statsNow, tt.interval)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
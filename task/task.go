@@ -2,39 +2,292 @@
 package task
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Task represents a task in the task list
 type Task struct {
-	Id           int    `json:"id"`
-	Name         string `json:"name"`
-	Status       string `json:"status"`
-	CreatedAt    string `json:"created_at"`
-	Owner        string `json:"owner"`
-	Collaborator string `json:"collaborator"`
+	Id          int        `json:"id"`
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	CreatedAt   string     `json:"created_at"`
+	Owner       string     `json:"owner"`
+	Assignees   []Assignee `json:"assignees"`
+	Priority    int        `json:"priority"`
+	Description string     `json:"description"`
+	// UpdatedAt and UpdatedBy record the last change and the member who
+	// made it. UpdatedBy is empty for tasks last changed by older versions.
+	UpdatedAt string `json:"updated_at"`
+	UpdatedBy string `json:"updated_by"`
+	// DueDate is the day the task is due as YYYY-MM-DD, or empty
+	DueDate string `json:"due_date"`
+	// CompletedAt is when the task was last marked done, or empty while it
+	// is open
+	CompletedAt string `json:"completed_at"`
+}
+
+// Overdue reports whether the task is open and was due before today
+func (t Task) Overdue(now time.Time) bool {
+	return t.Status != "done" && t.DueDate != "" && t.DueDate < now.Format(time.DateOnly)
+}
+
+// Role returns the role of a member on the task, or "" when they aren't
+// assigned to it
+func (t Task) Role(member string) string {
+	for _, a := range t.Assignees {
+		if a.Member == member {
+			return a.Role
+		}
+	}
+	return ""
+}
+
+// Members returns the names of the members assigned to the task with one of
+// the given roles, or with any role when none are given
+func (t Task) Members(roles ...string) []string {
+	var names []string
+	for _, a := range t.Assignees {
+		if len(roles) == 0 || containsString(roles, a.Role) {
+			names = append(names, a.Member)
+		}
+	}
+	return names
+}
+
+// Comment is a note left on a task by a member
+type Comment struct {
+	Id        int    `json:"id"`
+	TaskId    int    `json:"task_id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+}
+
+// MyWork is the work of the current member, as shown by task me. Each open
+// task they are assigned to is in exactly one of Overdue, Blocked, Owned and
+// Collaborating.
+type MyWork struct {
+	Member        string `json:"member"`
+	Overdue       []Task `json:"overdue"`
+	Blocked       []Task `json:"blocked"`
+	Owned         []Task `json:"owned"`
+	Collaborating []Task `json:"collaborating"`
+	// CompletedThisWeek holds their tasks done since Monday
+	CompletedThisWeek []Task `json:"completed_this_week"`
+}
+
+// TaskDetail is a task together with its comments
+type TaskDetail struct {
+	Task
+	Comments []Comment `json:"comments"`
+	// History is the task's creation and status changes, oldest first
+	History []TaskEvent `json:"history"`
+}
+
+// SearchResult is a task matched by a search. Name and Snippet contain the
+// matched terms wrapped in HighlightStart and HighlightEnd.
+type SearchResult struct {
+	Task    Task    `json:"task"`
+	Name    string  `json:"name"`
+	Snippet string  `json:"snippet,omitempty"`
+	Score   float64 `json:"score"`
+}
+
+// The built-in task statuses, in workflow order
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+)
+
+// Types of the task events kept in the task history. A status event's value
+// is the new status.
+const (
+	EventCreated = "created"
+	EventStatus  = "status"
+)
+
+// TaskEvent is a change in the history of a task
+type TaskEvent struct {
+	ID       int    `json:"id"`
+	TaskID   int    `json:"task_id"`
+	TaskName string `json:"task_name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	// Actor is the member who made the change
+	Actor     string `json:"actor"`
+	CreatedAt string `json:"created_at"`
+}
+
+// EventQuery selects task events. Zero values don't restrict the events.
+type EventQuery struct {
+	// TaskID limits the events to one task
+	TaskID int
+	// Member limits the events to the tasks a member is assigned to with any
+	// role and the changes they made to other tasks
+	Member string
+	// Since excludes older events
+	Since time.Time
+	// CurrentStatus only returns the status event that set the current
+	// status of each task
+	CurrentStatus bool
+}
+
+// Task priorities, from lowest to highest
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+var priorityNames = []string{"none", "low", "medium", "high"}
+
+// PriorityName returns the display name of a priority
+func PriorityName(priority int) string {
+	if priority < 0 || priority >= len(priorityNames) {
+		return strconv.Itoa(priority)
+	}
+	return priorityNames[priority]
+}
+
+// ParsePriority parses a priority given by name (low, medium, high) or number (0-3)
+func ParsePriority(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for i, name := range priorityNames {
+		if value == name || value == strconv.Itoa(i) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid priority %q: expected none, low, medium or high", value)
+}
+
+// ParseDueDate parses a due date given as YYYY-MM-DD, today or tomorrow,
+// relative to now. "none" and "" return "", which means no due date.
+func ParseDueDate(value string, now time.Time) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "", "none":
+		return "", nil
+	case "today":
+		return now.Format(time.DateOnly), nil
+	case "tomorrow":
+		return now.AddDate(0, 0, 1).Format(time.DateOnly), nil
+	}
+	if _, err := time.Parse(time.DateOnly, value); err != nil {
+		return "", fmt.Errorf("invalid due date %q: expected YYYY-MM-DD, today, tomorrow or none", value)
+	}
+	return value, nil
+}
+
+// Roles a member can have on a task. Every task has one owner, who is also
+// stored as Task.Owner; the other roles can be held by any number of members.
+const (
+	RoleOwner    = "owner"
+	RoleAssignee = "assignee"
+	RoleReviewer = "reviewer"
+	RoleWatcher  = "watcher"
+)
+
+// Roles lists the roles in the order they are shown
+var Roles = []string{RoleOwner, RoleAssignee, RoleReviewer, RoleWatcher}
+
+// ParseRole parses a role name
+func ParseRole(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !containsString(Roles, value) {
+		return "", fmt.Errorf("invalid role %q: expected %s", value, strings.Join(Roles, ", "))
+	}
+	return value, nil
+}
+
+// Assignee is a member assigned to a task with a role
+type Assignee struct {
+	Member string `json:"member"`
+	Role   string `json:"role"`
+}
+
+func (a Assignee) String() string {
+	return fmt.Sprintf("%s (%s)", a.Member, a.Role)
+}
+
+// Member roles, which decide what a member may do in a database. Admins can
+// do anything, members can change tasks but only delete their own, and
+// viewers can only read.
+const (
+	MemberRoleAdmin  = "admin"
+	MemberRoleMember = "member"
+	MemberRoleViewer = "viewer"
+)
+
+// MemberRoles lists the member roles from most to least permissive
+var MemberRoles = []string{MemberRoleAdmin, MemberRoleMember, MemberRoleViewer}
+
+// ParseMemberRole parses a member role name
+func ParseMemberRole(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !containsString(MemberRoles, value) {
+		return "", fmt.Errorf("invalid member role %q: expected %s", value, strings.Join(MemberRoles, ", "))
+	}
+	return value, nil
 }
 
 // Member represents a user in the system
 type Member struct {
 	Id        int    `json:"id"`
 	Name      string `json:"name"`
+	Role      string `json:"role"`
 	CreatedAt string `json:"created_at"`
+	// DeactivatedAt is set when the member left; their tasks and comments
+	// are kept but they can't act or be assigned any more
+	DeactivatedAt string `json:"deactivated_at,omitempty"`
+}
+
+// Active reports whether the member hasn't been deactivated
+func (m Member) Active() bool {
+	return m.DeactivatedAt == ""
+}
+
+// MemberUsage counts what in a database refers to a member
+type MemberUsage struct {
+	// Tasks is the number of tasks the member is assigned to with any role,
+	// and OpenTasks the number of those that aren't done
+	Tasks     int
+	OpenTasks int
+	// Changes is the number of task changes, history events and comments made
+	// by the member
+	Changes int
 }
 
-// NewTaskSchema is the schema for adding a new task
+// NewTaskSchema is the schema for adding a new task. Assignees and
+// Collaborator are assigned with the assignee role.
 type NewTaskSchema struct {
-	Name         string `json:"name"`
-	Collaborator string `json:"collaborator,omitempty"`
+	Name         string   `json:"name"`
+	Assignees    []string `json:"assignees,omitempty"`
+	Collaborator string   `json:"collaborator,omitempty"`
+	Priority     int      `json:"priority,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	DueDate      string   `json:"due_date,omitempty"`
 }
 
-// UpdateTaskSchema is the schema for updating a task
+// UpdateTaskSchema is the schema for updating a task. Collaborator is
+// assigned with the assignee role, in addition to the current assignees.
 type UpdateTaskSchema struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Status       string `json:"status"`
-	Completed    *bool  `json:"completed,omitempty"`
-	Collaborator string `json:"collaborator,omitempty"`
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Status       string  `json:"status"`
+	Completed    *bool   `json:"completed,omitempty"`
+	Collaborator string  `json:"collaborator,omitempty"`
+	Priority     *int    `json:"priority,omitempty"`
+	Description  *string `json:"description,omitempty"`
+	// DueDate changes the due date; an empty string removes it
+	DueDate *string `json:"due_date,omitempty"`
 }
 
 // ConnectionDetails is the details for connecting to an external database
@@ -80,59 +333,161 @@ func (c *ConnectionDetails) Validate() error {
 	return nil
 }
 
+// Errors returned by the repository and service. Callers can match them with
+// errors.Is; the returned errors wrap them with more detail.
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrTaskExists      = errors.New("task already exists")
+	ErrNoCurrentMember = errors.New("no current member set")
+	// ErrUnknownMember is matched when a task refers to a name that isn't in
+	// the members table. These errors also match ErrInvalidInput.
+	ErrUnknownMember = errors.New("unknown member")
+	// ErrLoginRequired is matched when the current member has a password and
+	// no valid login session
+	ErrLoginRequired = errors.New("login required")
+	// ErrPermissionDenied is matched when the current member's role doesn't
+	// allow the change
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrInvalidInput is matched by errors caused by the caller's input, such
+	// as an empty task name or a malformed filter, rather than by a failure
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// taskNotFound returns an ErrTaskNotFound error for the given task ID
+func taskNotFound(id int) error {
+	return fmt.Errorf("%w: no task with ID %d", ErrTaskNotFound, id)
+}
+
+// permissionDenied returns an ErrPermissionDenied error explaining what the
+// current member's role doesn't allow
+func permissionDenied(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrPermissionDenied, fmt.Sprintf(format, args...))
+}
+
+// inputError marks an error as matching ErrInvalidInput while keeping its
+// message
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e inputError) Unwrap() []error {
+	return []error{e.err, ErrInvalidInput}
+}
+
+// invalidInput returns an error matching ErrInvalidInput
+func invalidInput(format string, args ...interface{}) error {
+	return inputError{fmt.Errorf(format, args...)}
+}
+
+// IdentityStore keeps which member the local user is in each database. It is
+// kept outside the database, so people sharing a database file each have
+// their own current member.
+type IdentityStore interface {
+	// Identity returns the member name for a database, or "" when none is set
+	Identity(database string) (string, error)
+	SetIdentity(database, member string) error
+	// Session returns the token of the local user's login session for a
+	// database, or "" when there is none
+	Session(database string) (string, error)
+	SetSession(database, token string) error
+}
+
 // TaskRepository is the repository for task-related operations
 type TaskRepository interface {
 	// Task operations
-	AddTask(task Task) error
-	GetTask() ([]Task, error)
-	UpdateTask(id int, name string, status string, collaborator string) error
-	DoneTask(id int) error
-	GetTaskById(id int) (*Task, error)
-	DeleteTask(id int) error
+	AddTask(ctx context.Context, task Task) (*Task, error)
+	GetTask(ctx context.Context) ([]Task, error)
+	FilterTasks(ctx context.Context, filter TaskFilter) ([]Task, error)
+	QueryTasks(ctx context.Context, query TaskQuery) ([]Task, error)
+	UpdateTask(ctx context.Context, id int, name string, status string) error
+	DoneTask(ctx context.Context, id int) error
+	GetTaskById(ctx context.Context, id int) (*Task, error)
+	DeleteTask(ctx context.Context, id int) error
+	SetTaskPriority(ctx context.Context, id int, priority int) error
+	SetTaskDescription(ctx context.Context, id int, description string) error
+	SetTaskDueDate(ctx context.Context, id int, dueDate string) error
+	// GetMemberTasks returns the open tasks a member is assigned to with any
+	// role, and their done tasks completed since doneSince
+	GetMemberTasks(ctx context.Context, member string, doneSince time.Time) ([]Task, error)
+	GetTaskTimelines(ctx context.Context, member string) ([]TaskTimeline, error)
+	// GetTaskEvents returns the events selected by query, oldest first
+	GetTaskEvents(ctx context.Context, query EventQuery) ([]TaskEvent, error)
+	SearchTasks(ctx context.Context, query string) ([]SearchResult, error)
+	GetStatuses(ctx context.Context) ([]string, error)
+	AssignMembers(ctx context.Context, id int, role string, members []string) error
+	UnassignMembers(ctx context.Context, id int, members []string) error
+	FindOrphanedMembers(ctx context.Context) ([]OrphanedMember, error)
+
+	// Comment operations
+	AddComment(ctx context.Context, taskID int, body string) error
+	GetComments(ctx context.Context, taskID int) ([]Comment, error)
 
 	// Database operations
-	ConnectToExternalDB(details ConnectionDetails) error
+	ConnectToExternalDB(ctx context.Context, details ConnectionDetails) error
+	EnsureSchema(ctx context.Context) error
 
 	// Member operations
-	SetupMemberTable() error
-	GetCurrentMember() (string, error)
-	SetCurrentMember(name string) error
-	GetAllMembers() ([]Member, error)
-	AddMember(name string) error
+	SetupMemberTable(ctx context.Context) error
+	GetCurrentMember(ctx context.Context) (string, error)
+	SetCurrentMember(ctx context.Context, name string) error
+	GetAllMembers(ctx context.Context) ([]Member, error)
+	GetMember(ctx context.Context, name string) (*Member, error)
+	AddMember(ctx context.Context, name string) error
+	SetMemberRole(ctx context.Context, name string, role string) error
+	RenameMember(ctx context.Context, name string, newName string) error
+	GetMemberUsage(ctx context.Context, name string) (*MemberUsage, error)
+	DeactivateMember(ctx context.Context, name string, reassignTo string) (int, error)
+	ActivateMember(ctx context.Context, name string) error
+	RemoveMember(ctx context.Context, name string, reassignTo string) (int, error)
+	GetPasswordHash(ctx context.Context, name string) (string, error)
+	SetPasswordHash(ctx context.Context, name string, hash string) error
+	StartSession(ctx context.Context, name string, ttl time.Duration) (time.Time, error)
+	EndSession(ctx context.Context) error
 }
 
-// TaskService is the service for task-related operations
+// TaskService is the service for task-related operations. Methods return
+// data and errors and never print; rendering is left to the caller, see the
+// presenter package.
 type TaskService interface {
 	// Task management
-	HandleAdd(name string)
-	HandleList(completed bool, all bool)
-	HandleDone(id int)
-	HandleUpdate(data UpdateTaskSchema)
-	HandleDelete(id int)
-	HandleViewTask(id int, format string)
-	HandleViewAllTasks(format string)
+	AddTask(ctx context.Context, data NewTaskSchema) (*Task, error)
+	ListTasks(ctx context.Context, opts ListOptions) ([]Task, error)
+	GetTask(ctx context.Context, id int) (*TaskDetail, error)
+	CompleteTask(ctx context.Context, id int) (*Task, error)
+	UpdateTask(ctx context.Context, data UpdateTaskSchema) (*Task, error)
+	DeleteTask(ctx context.Context, id int) error
+	SearchTasks(ctx context.Context, query string) ([]SearchResult, error)
+	AddComment(ctx context.Context, id int, body string) error
+	ListStatuses(ctx context.Context) ([]string, error)
+	AssignTask(ctx context.Context, id int, role string, members []string) (*Task, error)
+	UnassignTask(ctx context.Context, id int, members []string) (*Task, error)
+	FindOrphans(ctx context.Context) ([]OrphanedMember, error)
+	MyWork(ctx context.Context) (*MyWork, error)
+	WorkloadReport(ctx context.Context) ([]MemberWorkload, error)
+	Stats(ctx context.Context, opts StatsOptions) (*Stats, error)
+	Standup(ctx context.Context, member string) (*Standup, error)
+	Digest(ctx context.Context, since string) (*Digest, error)
 
 	// Database connection
-	HandleConnect(details ConnectionDetails) error
-
-	// New methods with collaborators
-	HandleAddTask(name, collaborator string) error
-	HandleListTasks() error
-	HandleUpdateTask(id, name, status, collaborator string) error
-	HandleGetTask(id string) error
+	Connect(ctx context.Context, details ConnectionDetails) error
 
 	// Member management
-	HandleSetupMember() error
-	PromptForUsername() error
-	HandleListMembers() error
-	HandleAddMember(name string) error
-	HandleSetCurrentMember(name string) error
-}
-
-// ViewService is the service for rendering tasks in different formats
-type ViewService interface {
-	RenderTaskHTML(task Task) (string, error)
-	RenderAllTasksHTML(tasks []Task) (string, error)
+	ListMembers(ctx context.Context) ([]Member, error)
+	CurrentMember(ctx context.Context) (string, error)
+	AddMember(ctx context.Context, name string) error
+	SetMemberRole(ctx context.Context, name string, role string) error
+	RenameMember(ctx context.Context, name string, newName string) error
+	DeactivateMember(ctx context.Context, name string, reassignTo string) (int, error)
+	ActivateMember(ctx context.Context, name string) error
+	RemoveMember(ctx context.Context, name string, reassignTo string) (int, error)
+	SetCurrentMember(ctx context.Context, name string) error
+	SetPassword(ctx context.Context, name string, password string) error
+	Login(ctx context.Context, name string, password string, ttl time.Duration) (time.Time, error)
+	Logout(ctx context.Context) error
 }
 
 func (t *NewTaskSchema) Validate() bool {
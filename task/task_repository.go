@@ -1,36 +1,109 @@
 package task
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/ryuux05/task-cli/internal/logging"
+	_ "modernc.org/sqlite"
 )
 
 type TaskRepositoryImpl struct {
 	db *sql.DB
+	// identities holds the current member of each database for the local user
+	identities IdentityStore
+	// fts is true when the database supports the FTS5 search index
+	fts bool
 }
 
-func NewTaskRepository(db *sql.DB) TaskRepository {
+// taskColumns is the column list used by every task query. Queries must
+// alias tasks as t and status as s. The assignees are packed into one
+// column, see parseAssignees.
+const taskColumns = `t.id, t.name, s.name, t.created_at, t.owner, ` + assigneesColumn + `, t.priority, t.description,
+	t.updated_at, IFNULL(t.updated_by, ''), IFNULL(t.due_date, ''), t.completed_at`
+
+// assigneesColumn selects the assignees of task t as role<US>member pairs
+// separated by <RS>, ordered by role and then by when they were assigned
+const assigneesColumn = `IFNULL((
+	SELECT group_concat(a.role || char(31) || a.member, char(30) ORDER BY ` + roleOrder + `, a.id)
+	FROM task_assignees a WHERE a.task_id = t.id
+), '')`
+
+// roleOrder sorts the task_assignees rows aliased as a in the order of Roles
+const roleOrder = `CASE a.role WHEN 'owner' THEN 0 WHEN 'assignee' THEN 1 WHEN 'reviewer' THEN 2 ELSE 3 END`
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask scans a row selected with taskColumns, followed by the columns
+// in extra
+func scanTask(row rowScanner, extra ...interface{}) (Task, error) {
+	var task Task
+	var assignees string
+	var updatedAt, completedAt sql.NullString
+	dest := append([]interface{}{&task.Id, &task.Name, &task.Status, &task.CreatedAt, &task.Owner, &assignees, &task.Priority, &task.Description,
+		&updatedAt, &task.UpdatedBy, &task.DueDate, &completedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return task, err
+	}
+	task.Assignees = parseAssignees(assignees)
+	// Tasks not changed since updated_at was added count as updated when
+	// they were created
+	task.UpdatedAt = task.CreatedAt
+	if updatedAt.Valid {
+		task.UpdatedAt = updatedAt.String
+	}
+	task.CompletedAt = completedAt.String
+	return task, nil
+}
+
+// parseAssignees unpacks the assignees selected with assigneesColumn
+func parseAssignees(packed string) []Assignee {
+	assignees := []Assignee{}
+	if packed == "" {
+		return assignees
+	}
+	for _, pair := range strings.Split(packed, "\x1e") {
+		role, member, _ := strings.Cut(pair, "\x1f")
+		assignees = append(assignees, Assignee{Member: member, Role: role})
+	}
+	return assignees
+}
+
+func NewTaskRepository(db *sql.DB, identities IdentityStore) TaskRepository {
 	return &TaskRepositoryImpl{
-		db: db,
+		db:         db,
+		identities: identities,
 	}
 }
 
-func (r *TaskRepositoryImpl) AddTask(task Task) error {
-	fmt.Println("Adding task:", task.Name)
+// AddTask inserts a task and returns it as stored. Returns ErrTaskExists when
+// the owner already has a task with the same name.
+func (r *TaskRepositoryImpl) AddTask(ctx context.Context, task Task) (*Task, error) {
+	logging.Debugf("Adding task: %v", task.Name)
 
 	// Check if the tasks table exists
 	var tableExists int
-	err := r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='tasks'").Scan(&tableExists)
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='tasks'").Scan(&tableExists)
 	if err != nil {
-		return fmt.Errorf("failed to check if tasks table exists: %v", err)
+		return nil, fmt.Errorf("failed to check if tasks table exists: %v", err)
 	}
 
 	if tableExists == 0 {
-		fmt.Println("Tasks table does not exist, creating it...")
-		_, err := r.db.Exec(`
+		logging.Debugf("Tasks table does not exist, creating it...")
+		_, err := r.db.ExecContext(ctx, `
 			CREATE TABLE IF NOT EXISTS tasks (
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				name TEXT NOT NULL,
@@ -38,90 +111,113 @@ func (r *TaskRepositoryImpl) AddTask(task Task) error {
 				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 				owner TEXT NOT NULL,
 				collaborator TEXT,
+				priority INTEGER NOT NULL DEFAULT 0,
+				description TEXT NOT NULL DEFAULT '',
+				updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				updated_by TEXT NOT NULL DEFAULT '',
+				due_date DATE,
+				completed_at TIMESTAMP,
 				FOREIGN KEY (status) REFERENCES status(id),
 				FOREIGN KEY (owner) REFERENCES members(name),
 				FOREIGN KEY (collaborator) REFERENCES members(name)
 			)
 		`)
 		if err != nil {
-			return fmt.Errorf("failed to create tasks table: %v", err)
+			return nil, fmt.Errorf("failed to create tasks table: %v", err)
 		}
 
 		// Also check if status table exists
-		err = r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='status'").Scan(&tableExists)
+		err = r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='status'").Scan(&tableExists)
 		if err != nil {
-			return fmt.Errorf("failed to check if status table exists: %v", err)
+			return nil, fmt.Errorf("failed to check if status table exists: %v", err)
 		}
 
 		if tableExists == 0 {
-			fmt.Println("Status table does not exist, creating it...")
-			_, err := r.db.Exec(`
+			logging.Debugf("Status table does not exist, creating it...")
+			_, err := r.db.ExecContext(ctx, `
 				CREATE TABLE IF NOT EXISTS status (
 					id INTEGER PRIMARY KEY AUTOINCREMENT,
 					name TEXT NOT NULL UNIQUE
 				)
 			`)
 			if err != nil {
-				return fmt.Errorf("failed to create status table: %v", err)
+				return nil, fmt.Errorf("failed to create status table: %v", err)
 			}
 
 			// Add default statuses
-			_, err = r.db.Exec("INSERT INTO status (id, name) VALUES (1, 'pending'), (2, 'done')")
+			_, err = r.db.ExecContext(ctx, "INSERT INTO status (id, name) VALUES (1, 'pending'), (2, 'done'), (3, 'in_progress'), (4, 'blocked')")
 			if err != nil {
-				return fmt.Errorf("failed to add default statuses: %v", err)
+				return nil, fmt.Errorf("failed to add default statuses: %v", err)
 			}
 		}
 	}
 
 	// If owner is not set, get the current member
 	if task.Owner == "" {
-		owner, err := r.GetCurrentMember()
+		owner, err := r.GetCurrentMember(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get current member: %v", err)
+			return nil, fmt.Errorf("failed to get current member: %w", err)
 		}
 		task.Owner = owner
 	}
 
-	// If collaborator is set, ensure the member exists
-	if task.Collaborator != "" {
-		if err := r.AddMember(task.Collaborator); err != nil {
-			return err
-		}
-	}
+	logging.Debugf("Adding task with owner: %s, assignees: %v", task.Owner, task.Assignees)
 
-	fmt.Printf("Adding task with owner: %s, collaborator: %s\n", task.Owner, task.Collaborator)
+	actor, err := r.actor(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	query := `
-		INSERT INTO tasks (name, status, owner, collaborator)
-        SELECT ?, (SELECT id FROM status WHERE name = 'pending'), ?, ?
+		INSERT INTO tasks (name, status, owner, priority, description, due_date, updated_at, updated_by)
+        SELECT ?, (SELECT id FROM status WHERE name = 'pending'), ?, ?, ?, NULLIF(?, ''), CURRENT_TIMESTAMP, ?
         WHERE NOT EXISTS (SELECT 1 FROM tasks WHERE name = ? AND owner = ?);
 	`
-	res, err := r.db.Exec(query, task.Name, task.Owner, task.Collaborator, task.Name, task.Owner)
+	res, err := r.db.ExecContext(ctx, query, task.Name, task.Owner, task.Priority, task.Description, task.DueDate, actor, task.Name, task.Owner)
 	if err != nil {
-		return fmt.Errorf("Failed to execute query: %v", err)
+		return nil, fmt.Errorf("Failed to execute query: %v", err)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("Failed to execute query: %v", err)
+		return nil, fmt.Errorf("Failed to execute query: %v", err)
 	}
 
 	if rowsAffected == 0 {
-		fmt.Println("Task already exists for this owner, skipping insert.")
-	} else {
-		fmt.Println("Task added successfully.")
+		return nil, fmt.Errorf("%w: %q is already owned by %s", ErrTaskExists, task.Name, task.Owner)
 	}
-	return nil
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get task ID: %v", err)
+	}
+	if _, err := r.db.ExecContext(ctx, "INSERT INTO task_events (task_id, type, actor) VALUES (?, ?, ?)", id, EventCreated, actor); err != nil {
+		return nil, fmt.Errorf("Failed to record task history: %v", err)
+	}
+
+	if err := r.AssignMembers(ctx, int(id), RoleOwner, []string{task.Owner}); err != nil {
+		return nil, err
+	}
+	for _, a := range task.Assignees {
+		if a.Member == task.Owner {
+			continue
+		}
+		if err := r.AssignMembers(ctx, int(id), a.Role, []string{a.Member}); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.GetTaskById(ctx, int(id))
 }
 
-func (r *TaskRepositoryImpl) GetTask() ([]Task, error) {
+func (r *TaskRepositoryImpl) GetTask(ctx context.Context) ([]Task, error) {
 	query := `
-		SELECT t.id, t.name, s.name, t.created_at, t.owner, IFNULL(t.collaborator, '')
+		SELECT ` + taskColumns + `
         FROM tasks t
         JOIN status s ON t.status = s.id;
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return make([]Task, 0), fmt.Errorf("Failed to execute query: %v", err)
 	}
@@ -129,8 +225,8 @@ func (r *TaskRepositoryImpl) GetTask() ([]Task, error) {
 
 	var tasks []Task
 	for rows.Next() {
-		var task Task
-		if err := rows.Scan(&task.Id, &task.Name, &task.Status, &task.CreatedAt, &task.Owner, &task.Collaborator); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return make([]Task, 0), fmt.Errorf("Failed to scan result: %v", err)
 		}
 		tasks = append(tasks, task)
@@ -139,361 +235,1310 @@ func (r *TaskRepositoryImpl) GetTask() ([]Task, error) {
 	return tasks, nil
 }
 
-func (r *TaskRepositoryImpl) DoneTask(id int) error {
-	query := `
-		UPDATE tasks 
-		SET status = (SELECT id FROM status WHERE name = 'done')
-		WHERE id = ?
-	`
+// FilterTasks returns the tasks matching the given filter
+func (r *TaskRepositoryImpl) FilterTasks(ctx context.Context, filter TaskFilter) ([]Task, error) {
+	return r.QueryTasks(ctx, TaskQuery{Filter: filter})
+}
 
-	res, err := r.db.Exec(query, id)
+// QueryTasks returns the tasks matching the query, sorted and paginated
+// in the database
+func (r *TaskRepositoryImpl) QueryTasks(ctx context.Context, q TaskQuery) ([]Task, error) {
+	where, args, err := buildFilterClause(q.Filter)
 	if err != nil {
-		return fmt.Errorf("Failed to mark task as done: %v", err)
+		return make([]Task, 0), err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	orderBy, err := buildOrderByClause(q.Sort)
 	if err != nil {
-		return fmt.Errorf("Failed to get affected rows: %v", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("No task found with ID %d", id)
-	}
-
-	return nil
-}
-
-func (r *TaskRepositoryImpl) UpdateTask(id int, name string, status string, collaborator string) error {
-	// First, check if the collaborator exists and add them if needed
-	if collaborator != "" {
-		if err := r.AddMember(collaborator); err != nil {
-			return err
-		}
+		return make([]Task, 0), err
 	}
 
-	// Ensure status exists in the status table
 	query := `
-		UPDATE tasks 
-		SET name = ?,
-			status = (SELECT id FROM status WHERE name = ?),
-			collaborator = ?
-		WHERE id = ?
+		SELECT ` + taskColumns + `
+		FROM tasks t
+		JOIN status s ON t.status = s.id
 	`
+	if where != "" {
+		query += " WHERE " + where
+	}
+	query += " ORDER BY " + orderBy
 
-	res, err := r.db.Exec(query, name, status, collaborator, id)
-	if err != nil {
-		return fmt.Errorf("Failed to update task: %v", err)
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := int64(math.MaxInt64)
+		if q.Limit > 0 {
+			limit = int64(q.Limit)
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, q.Offset)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("Failed to get affected rows: %v", err)
+		return make([]Task, 0), fmt.Errorf("Failed to execute query: %v", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("No task found with ID %d", id)
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return make([]Task, 0), fmt.Errorf("Failed to scan result: %v", err)
+		}
+		tasks = append(tasks, task)
 	}
 
-	return nil
+	return tasks, nil
 }
 
-func (r *TaskRepositoryImpl) GetTaskById(id int) (*Task, error) {
+// GetMemberTasks returns the open tasks a member is assigned to with any
+// role, and their done tasks completed since doneSince, ordered by due date
+// and then by priority
+func (r *TaskRepositoryImpl) GetMemberTasks(ctx context.Context, member string, doneSince time.Time) ([]Task, error) {
 	query := `
-		SELECT t.id, t.name, s.name, t.created_at, t.owner, IFNULL(t.collaborator, '')
+		SELECT ` + taskColumns + `
 		FROM tasks t
 		JOIN status s ON t.status = s.id
-		WHERE t.id = ?
+		WHERE EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = t.id AND a.member = ?)
+		AND (s.name != 'done' OR t.completed_at >= ?)
+		ORDER BY IFNULL(t.due_date, '9999-12-31'), t.priority DESC, t.id
 	`
-
-	var task Task
-	err := r.db.QueryRow(query, id).Scan(&task.Id, &task.Name, &task.Status, &task.CreatedAt, &task.Owner, &task.Collaborator)
+	rows, err := r.db.QueryContext(ctx, query, member, doneSince.UTC().Format(time.DateTime))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("No task found with ID %d", id)
-		}
-		return nil, fmt.Errorf("Failed to query task: %v", err)
+		return nil, fmt.Errorf("failed to query member tasks: %v", err)
 	}
+	defer rows.Close()
 
-	return &task, nil
-}
-
-// DeleteTask deletes a task with the given ID
-func (r *TaskRepositoryImpl) DeleteTask(id int) error {
-	query := `DELETE FROM tasks WHERE id = ?`
-	res, err := r.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("Failed to delete task: %v", err)
+	var tasks []Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %v", err)
+		}
+		tasks = append(tasks, task)
 	}
+	return tasks, rows.Err()
+}
 
-	rowsAffected, err := res.RowsAffected()
+// GetTaskTimelines returns when each task was created, first started and
+// completed. When member is set, only the tasks they are assigned to with
+// any role are returned.
+func (r *TaskRepositoryImpl) GetTaskTimelines(ctx context.Context, member string) ([]TaskTimeline, error) {
+	query := `
+		SELECT t.id, t.created_at, t.completed_at,
+			(SELECT MIN(e.created_at) FROM task_events e
+				WHERE e.task_id = t.id AND e.type = ? AND e.value = ?)
+		FROM tasks t
+		WHERE ? = '' OR EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = t.id AND a.member = ?)
+		ORDER BY t.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, EventStatus, StatusInProgress, member, member)
 	if err != nil {
-		return fmt.Errorf("Failed to get affected rows: %v", err)
+		return nil, fmt.Errorf("failed to query task history: %v", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("No task found with ID %d", id)
+	var timelines []TaskTimeline
+	for rows.Next() {
+		var timeline TaskTimeline
+		var createdAt, completedAt, startedAt sql.NullString
+		if err := rows.Scan(&timeline.ID, &createdAt, &completedAt, &startedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task history: %v", err)
+		}
+		timeline.CreatedAt, _ = ParseTimestamp(createdAt.String)
+		timeline.CompletedAt, _ = ParseTimestamp(completedAt.String)
+		timeline.StartedAt, _ = ParseTimestamp(startedAt.String)
+		timelines = append(timelines, timeline)
 	}
-
-	return nil
+	return timelines, rows.Err()
 }
 
-// ConnectToExternalDB connects to an external database
-func (r *TaskRepositoryImpl) ConnectToExternalDB(details ConnectionDetails) error {
-	fmt.Println("Connecting to database...")
-
-	// Close existing database connection if any
-	if r.db != nil {
-		fmt.Println("Closing existing database connection...")
-		if err := r.db.Close(); err != nil {
-			return fmt.Errorf("failed to close existing database connection: %v", err)
-		}
+// GetTaskEvents returns the events selected by query, oldest first, with the
+// names of their tasks
+func (r *TaskRepositoryImpl) GetTaskEvents(ctx context.Context, query EventQuery) ([]TaskEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if query.TaskID != 0 {
+		conditions = append(conditions, "e.task_id = ?")
+		args = append(args, query.TaskID)
 	}
-
-	// For now, just use SQLite in file mode for demonstration
-	sqlitePath := "storage/tasks.db"
-	if details.Team != "" {
-		sqlitePath = fmt.Sprintf("storage/team_%s.db", details.Team)
+	if query.Member != "" {
+		conditions = append(conditions, "(e.actor = ? OR EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = e.task_id AND a.member = ?))")
+		args = append(args, query.Member, query.Member)
+	}
+	if !query.Since.IsZero() {
+		conditions = append(conditions, "e.created_at >= ?")
+		args = append(args, query.Since.UTC().Format(time.DateTime))
+	}
+	if query.CurrentStatus {
+		conditions = append(conditions, `e.id = (SELECT MAX(l.id) FROM task_events l WHERE l.task_id = e.task_id AND l.type = ?)`)
+		args = append(args, EventStatus)
 	}
 
-	// Ensure storage directory exists
-	fmt.Println("Ensuring storage directory exists...")
-	if err := os.MkdirAll("storage", 0755); err != nil {
-		return fmt.Errorf("failed to create storage directory: %v", err)
+	stmt := `
+		SELECT e.id, e.task_id, t.name, e.type, e.value, e.actor, e.created_at
+		FROM task_events e
+		JOIN tasks t ON t.id = e.task_id
+	`
+	if len(conditions) > 0 {
+		stmt += " WHERE " + strings.Join(conditions, " AND ")
 	}
+	stmt += " ORDER BY e.created_at, e.id"
 
-	fmt.Printf("Opening database connection to %s...\n", sqlitePath)
-	// Open the database connection
-	db, err := sql.Open("sqlite3", sqlitePath)
+	rows, err := r.db.QueryContext(ctx, stmt, args...)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return nil, fmt.Errorf("failed to query task history: %v", err)
 	}
+	defer rows.Close()
 
-	// Test the connection
-	fmt.Println("Testing database connection...")
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %v", err)
+	var events []TaskEvent
+	for rows.Next() {
+		var event TaskEvent
+		var createdAt sql.NullString
+		if err := rows.Scan(&event.ID, &event.TaskID, &event.TaskName, &event.Type, &event.Value, &event.Actor, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan task event: %v", err)
+		}
+		event.CreatedAt = createdAt.String
+		events = append(events, event)
 	}
+	return events, rows.Err()
+}
 
-	// Set the connection in the repository
-	r.db = db
+// sortColumns maps sort fields to the SQL expressions they order by
+var sortColumns = map[string]string{
+	"id":        "t.id",
+	"name":      "t.name",
+	"status":    "s.name",
+	"priority":  "t.priority",
+	"owner":     "t.owner",
+	"assignees": "(SELECT group_concat(a.member, ', ' ORDER BY " + roleOrder + ", a.id) FROM task_assignees a WHERE a.task_id = t.id AND a.role != 'owner')",
+	"created":   "t.created_at",
+	"due":       "IFNULL(t.due_date, '9999-12-31')",
+}
 
-	// Ensure required tables exist
-	fmt.Println("Ensuring tables exist...")
-	if err := r.ensureTablesExist(); err != nil {
-		return fmt.Errorf("failed to ensure tables exist: %v", err)
+// buildOrderByClause compiles sort fields into an ORDER BY clause. The task ID
+// is always appended as a tie-breaker so pages are stable.
+func buildOrderByClause(sort []SortField) (string, error) {
+	var clauses []string
+	for _, f := range sort {
+		column, ok := sortColumns[f.Field]
+		if !ok {
+			return "", fmt.Errorf("unknown sort field %q", f.Field)
+		}
+		if f.Desc {
+			clauses = append(clauses, column+" DESC")
+		} else {
+			clauses = append(clauses, column+" ASC")
+		}
 	}
-
-	return nil
+	clauses = append(clauses, "t.id ASC")
+	return strings.Join(clauses, ", "), nil
 }
 
-// ensureTablesExist checks if the necessary tables exist in the database
-// and creates them if they don't
-func (r *TaskRepositoryImpl) ensureTablesExist() error {
-	fmt.Println("Creating database tables if needed...")
+// filterColumns maps filter fields to the SQL expressions they compare against
+var filterColumns = map[string]string{
+	"id":       "t.id",
+	"name":     "t.name",
+	"status":   "s.name",
+	"priority": "t.priority",
+	"owner":    "t.owner",
+	"created":  "DATE(t.created_at)",
+	"due":      "t.due_date",
+}
 
-	// Run each statement separately for better error handling
-	statements := []string{
-		// Create status table
-		`CREATE TABLE IF NOT EXISTS status (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL UNIQUE
-		)`,
+// filterRoles maps the filter fields that match assignees to their role
+var filterRoles = map[string]string{
+	"assignee":     RoleAssignee,
+	"collaborator": RoleAssignee,
+	"reviewer":     RoleReviewer,
+	"watcher":      RoleWatcher,
+}
 
-		// Create members table
-		`CREATE TABLE IF NOT EXISTS members (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL UNIQUE,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)`,
+// buildFilterClause compiles a filter into a parameterized WHERE clause.
+// Only whitelisted columns and operators are interpolated; all values are bound.
+func buildFilterClause(filter TaskFilter) (string, []interface{}, error) {
+	var clauses []string
+	var args []interface{}
 
-		// Create current_member table
-		`CREATE TABLE IF NOT EXISTS current_member (
-			id INTEGER PRIMARY KEY,
-			member_name TEXT NOT NULL,
-			FOREIGN KEY (member_name) REFERENCES members(name)
-		)`,
+	for _, c := range filter.Conditions {
+		if role, ok := filterRoles[c.Field]; ok {
+			clause, arg, err := assigneeClause(role, c)
+			if err != nil {
+				return "", nil, err
+			}
+			clauses = append(clauses, clause)
+			args = append(args, role, arg)
+			continue
+		}
 
-		// Create tasks table
-		`CREATE TABLE IF NOT EXISTS tasks (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			status INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			owner TEXT NOT NULL,
-			collaborator TEXT,
-			FOREIGN KEY (status) REFERENCES status(id),
-			FOREIGN KEY (owner) REFERENCES members(name),
-			FOREIGN KEY (collaborator) REFERENCES members(name)
-		)`,
+		column, ok := filterColumns[c.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown filter field %q", c.Field)
+		}
 
-		// Add default statuses if not exist
-		`INSERT OR IGNORE INTO status (id, name) VALUES (1, 'pending'), (2, 'done')`,
+		switch c.Op {
+		case OpEqual, OpNotEqual, OpGreater, OpGreaterEqual, OpLess, OpLessEqual:
+			clauses = append(clauses, fmt.Sprintf("%s %s ?", column, c.Op))
+			if c.Field == "id" {
+				id, err := strconv.Atoi(c.Value)
+				if err != nil {
+					return "", nil, fmt.Errorf("invalid task ID in filter: %s", c.Value)
+				}
+				args = append(args, id)
+			} else if c.Field == "priority" {
+				priority, err := ParsePriority(c.Value)
+				if err != nil {
+					return "", nil, err
+				}
+				args = append(args, priority)
+			} else {
+				args = append(args, c.Value)
+			}
+		case OpContains:
+			clauses = append(clauses, fmt.Sprintf("%s LIKE ? ESCAPE '\\'", column))
+			args = append(args, "%"+escapeLike(c.Value)+"%")
+		case OpNotContains:
+			clauses = append(clauses, fmt.Sprintf("%s NOT LIKE ? ESCAPE '\\'", column))
+			args = append(args, "%"+escapeLike(c.Value)+"%")
+		default:
+			return "", nil, fmt.Errorf("unsupported filter operator %q", c.Op)
+		}
 	}
 
-	// Execute each SQL statement
-	for _, stmt := range statements {
-		_, err := r.db.Exec(stmt)
-		if err != nil {
-			return fmt.Errorf("error executing SQL statement: %v\nStatement: %s", err, stmt)
-		}
+	return strings.Join(clauses, " AND "), args, nil
+}
+
+// assigneeClause compiles a condition on the members with a role. Positive
+// conditions match tasks where any such member matches, negative ones tasks
+// where none does. The clause takes the role and the returned value as
+// arguments.
+func assigneeClause(role string, c FilterCondition) (string, interface{}, error) {
+	const exists = "EXISTS (SELECT 1 FROM task_assignees a WHERE a.task_id = t.id AND a.role = ? AND a.member %s)"
+	switch c.Op {
+	case OpEqual:
+		return fmt.Sprintf(exists, "= ?"), c.Value, nil
+	case OpNotEqual:
+		return "NOT " + fmt.Sprintf(exists, "= ?"), c.Value, nil
+	case OpContains:
+		return fmt.Sprintf(exists, "LIKE ? ESCAPE '\\'"), "%" + escapeLike(c.Value) + "%", nil
+	case OpNotContains:
+		return "NOT " + fmt.Sprintf(exists, "LIKE ? ESCAPE '\\'"), "%" + escapeLike(c.Value) + "%", nil
 	}
+	return "", nil, fmt.Errorf("operator %s is not supported for %s", c.Op, c.Field)
+}
 
-	fmt.Println("Database tables created successfully.")
-	return nil
+// escapeLike escapes the LIKE wildcards in a user supplied value
+func escapeLike(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "%", `\%`)
+	value = strings.ReplaceAll(value, "_", `\_`)
+	return value
 }
 
-// SetupMemberTable ensures the members table is set up and prompts for username if needed
-func (r *TaskRepositoryImpl) SetupMemberTable() error {
-	fmt.Println("Setting up member table...")
+func (r *TaskRepositoryImpl) DoneTask(ctx context.Context, id int) error {
+	return r.UpdateTask(ctx, id, "", StatusDone)
+}
 
-	// Check if the tables exist first
-	var tableExists int
-	err := r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='members'").Scan(&tableExists)
+// SetTaskPriority sets the priority of a task
+func (r *TaskRepositoryImpl) SetTaskPriority(ctx context.Context, id int, priority int) error {
+	actor, err := r.actor(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check if members table exists: %v", err)
+		return err
 	}
 
-	if tableExists == 0 {
-		fmt.Println("Members table does not exist, creating it...")
-		_, err := r.db.Exec(`
-			CREATE TABLE IF NOT EXISTS members (
-				id INTEGER PRIMARY KEY AUTOINCREMENT,
-				name TEXT NOT NULL UNIQUE,
-				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-			)
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to create members table: %v", err)
-		}
+	res, err := r.db.ExecContext(ctx, "UPDATE tasks SET priority = ?, updated_at = CURRENT_TIMESTAMP, updated_by = ? WHERE id = ?", priority, actor, id)
+	if err != nil {
+		return fmt.Errorf("Failed to update task priority: %v", err)
 	}
 
-	err = r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='current_member'").Scan(&tableExists)
+	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check if current_member table exists: %v", err)
+		return fmt.Errorf("Failed to get affected rows: %v", err)
 	}
 
-	if tableExists == 0 {
-		fmt.Println("Current member table does not exist, creating it...")
-		_, err := r.db.Exec(`
-			CREATE TABLE IF NOT EXISTS current_member (
-				id INTEGER PRIMARY KEY,
-				member_name TEXT NOT NULL,
-				FOREIGN KEY (member_name) REFERENCES members(name)
-			)
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to create current_member table: %v", err)
-		}
+	if rowsAffected == 0 {
+		return taskNotFound(id)
 	}
 
-	// Check if we have any members
-	var count int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM members").Scan(&count)
+	return nil
+}
+
+// SetTaskDescription sets the description of a task
+func (r *TaskRepositoryImpl) SetTaskDescription(ctx context.Context, id int, description string) error {
+	actor, err := r.actor(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to check members table: %v", err)
+		return err
 	}
 
-	// Check if we have a current member set
-	var currentMemberCount int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM current_member").Scan(&currentMemberCount)
+	res, err := r.db.ExecContext(ctx, "UPDATE tasks SET description = ?, updated_at = CURRENT_TIMESTAMP, updated_by = ? WHERE id = ?", description, actor, id)
 	if err != nil {
-		return fmt.Errorf("failed to check current member: %v", err)
+		return fmt.Errorf("Failed to update task description: %v", err)
 	}
 
-	fmt.Printf("Member setup complete. Members: %d, Current member set: %t\n", count, currentMemberCount > 0)
-	return nil
-}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get affected rows: %v", err)
+	}
 
-// GetCurrentMember returns the name of the current member
-func (r *TaskRepositoryImpl) GetCurrentMember() (string, error) {
-	fmt.Println("Getting current member...")
+	if rowsAffected == 0 {
+		return taskNotFound(id)
+	}
 
-	var name string
+	return nil
+}
 
-	// Check if current_member table has any rows
-	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM current_member").Scan(&count)
+// SetTaskDueDate sets the due date of a task as YYYY-MM-DD. An empty date
+// removes it.
+func (r *TaskRepositoryImpl) SetTaskDueDate(ctx context.Context, id int, dueDate string) error {
+	actor, err := r.actor(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to check current member: %v", err)
+		return err
 	}
 
-	if count == 0 {
-		return "", fmt.Errorf("no current member set")
+	res, err := r.db.ExecContext(ctx, "UPDATE tasks SET due_date = NULLIF(?, ''), updated_at = CURRENT_TIMESTAMP, updated_by = ? WHERE id = ?", dueDate, actor, id)
+	if err != nil {
+		return fmt.Errorf("Failed to update task due date: %v", err)
 	}
 
-	// Get the current member name
-	err = r.db.QueryRow("SELECT member_name FROM current_member LIMIT 1").Scan(&name)
+	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return "", fmt.Errorf("failed to get current member: %v", err)
+		return fmt.Errorf("Failed to get affected rows: %v", err)
 	}
 
-	fmt.Printf("Current member is: %s\n", name)
-	return name, nil
-}
+	if rowsAffected == 0 {
+		return taskNotFound(id)
+	}
 
-// SetCurrentMember sets the current member
-func (r *TaskRepositoryImpl) SetCurrentMember(name string) error {
-	fmt.Printf("Setting current member to: %s\n", name)
+	return nil
+}
 
-	// First, ensure the member exists
-	err := r.AddMember(name)
+func (r *TaskRepositoryImpl) UpdateTask(ctx context.Context, id int, name string, status string) error {
+	actor, err := r.actor(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Check if the current_member table exists
-	var tableExists int
-	err = r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='current_member'").Scan(&tableExists)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to check if current_member table exists: %v", err)
+		return fmt.Errorf("Failed to start transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	if tableExists == 0 {
-		fmt.Println("Current member table does not exist, creating it...")
-		_, err := r.db.Exec(`
-			CREATE TABLE IF NOT EXISTS current_member (
-				id INTEGER PRIMARY KEY,
-				member_name TEXT NOT NULL,
-				FOREIGN KEY (member_name) REFERENCES members(name)
-			)
-		`)
-		if err != nil {
-			return fmt.Errorf("failed to create current_member table: %v", err)
-		}
-	}
+	// An empty name keeps the current one
+	query := `
+		UPDATE tasks 
+		SET name = COALESCE(NULLIF(?, ''), name),
+			updated_at = CURRENT_TIMESTAMP, updated_by = ?
+		WHERE id = ?
+	`
 
-	// Clear existing current member
-	fmt.Println("Clearing current member table...")
-	_, err = r.db.Exec("DELETE FROM current_member")
+	res, err := tx.ExecContext(ctx, query, name, actor, id)
 	if err != nil {
-		return fmt.Errorf("failed to clear current member: %v", err)
+		return fmt.Errorf("Failed to update task: %v", err)
 	}
 
-	// Set new current member
-	fmt.Println("Setting new current member...")
-	_, err = r.db.Exec("INSERT INTO current_member (id, member_name) VALUES (1, ?)", name)
+	rowsAffected, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to set current member: %v", err)
+		return fmt.Errorf("Failed to get affected rows: %v", err)
 	}
 
-	fmt.Printf("Current member set to: %s\n", name)
-	return nil
-}
-
-// GetAllMembers returns all members
-func (r *TaskRepositoryImpl) GetAllMembers() ([]Member, error) {
-	query := "SELECT id, name, created_at FROM members ORDER BY name"
+	if rowsAffected == 0 {
+		return taskNotFound(id)
+	}
 
-	rows, err := r.db.Query(query)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query members: %v", err)
+	if err := changeStatus(ctx, tx, id, status, actor); err != nil {
+		return err
 	}
-	defer rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Failed to update task: %v", err)
+	}
+	return nil
+}
+
+// changeStatus sets the status of a task and records the change in
+// task_events. completed_at is set when the task becomes done and cleared
+// when it is reopened. Empty and unknown statuses and the current status
+// change nothing.
+func changeStatus(ctx context.Context, tx *sql.Tx, id int, status string, actor string) error {
+	query := `
+		UPDATE tasks
+		SET status = (SELECT id FROM status WHERE name = ?),
+			completed_at = CASE WHEN ? = 'done' THEN CURRENT_TIMESTAMP END
+		WHERE id = ?
+			AND status != (SELECT id FROM status WHERE name = ?)
+			AND EXISTS (SELECT 1 FROM status WHERE name = ?)
+	`
+	res, err := tx.ExecContext(ctx, query, status, status, id, status, status)
+	if err != nil {
+		return fmt.Errorf("Failed to update task status: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get affected rows: %v", err)
+	}
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO task_events (task_id, type, value, actor) VALUES (?, ?, ?, ?)", id, EventStatus, status, actor)
+	if err != nil {
+		return fmt.Errorf("Failed to record task history: %v", err)
+	}
+	return nil
+}
+
+func (r *TaskRepositoryImpl) GetTaskById(ctx context.Context, id int) (*Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks t
+		JOIN status s ON t.status = s.id
+		WHERE t.id = ?
+	`
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, taskNotFound(id)
+		}
+		return nil, fmt.Errorf("Failed to query task: %v", err)
+	}
+
+	return &task, nil
+}
+
+// AssignMembers gives members a role on a task. The members are not checked
+// against the members table; TaskService does that. A member has one role per
+// task, so assigning a member again changes their role. Assigning the owner role transfers ownership: the
+// previous owner is no longer assigned.
+func (r *TaskRepositoryImpl) AssignMembers(ctx context.Context, id int, role string, members []string) error {
+	if _, err := r.GetTaskById(ctx, id); err != nil {
+		return err
+	}
+	if role == RoleOwner && len(members) != 1 {
+		return invalidInput("a task has exactly one owner")
+	}
+	actor, err := r.actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if role == RoleOwner {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM task_assignees WHERE task_id = ? AND role = ?", id, RoleOwner); err != nil {
+			return fmt.Errorf("failed to change owner: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET owner = ? WHERE id = ?", members[0], id); err != nil {
+			return fmt.Errorf("failed to change owner: %v", err)
+		}
+	}
+
+	for _, member := range members {
+		var current string
+		err := tx.QueryRowContext(ctx, "SELECT role FROM task_assignees WHERE task_id = ? AND member = ?", id, member).Scan(&current)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			_, err = tx.ExecContext(ctx, "INSERT INTO task_assignees (task_id, member, role) VALUES (?, ?, ?)", id, member, role)
+		case err != nil:
+		case current == RoleOwner:
+			return invalidInput("%s owns task %d; assign another owner first", member, id)
+		default:
+			_, err = tx.ExecContext(ctx, "UPDATE task_assignees SET role = ? WHERE task_id = ? AND member = ?", role, id, member)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to assign %s: %v", member, err)
+		}
+	}
+	if err := touchTask(ctx, tx, id, actor); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to assign members: %v", err)
+	}
+	return nil
+}
+
+// touchTask records that actor changed task id
+func touchTask(ctx context.Context, tx *sql.Tx, id int, actor string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE tasks SET updated_at = CURRENT_TIMESTAMP, updated_by = ? WHERE id = ?", actor, id)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %v", err)
+	}
+	return nil
+}
+
+// UnassignMembers removes members from a task. The owner can't be removed,
+// only replaced, see AssignMembers.
+func (r *TaskRepositoryImpl) UnassignMembers(ctx context.Context, id int, members []string) error {
+	task, err := r.GetTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		if member == task.Owner {
+			return invalidInput("%s owns task %d; assign another owner instead", member, id)
+		}
+		if !containsString(task.Members(), member) {
+			return invalidInput("%s is not assigned to task %d", member, id)
+		}
+	}
+	actor, err := r.actor(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, member := range members {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM task_assignees WHERE task_id = ? AND member = ?", id, member); err != nil {
+			return fmt.Errorf("failed to unassign %s: %v", member, err)
+		}
+	}
+	if err := touchTask(ctx, tx, id, actor); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to unassign members: %v", err)
+	}
+	return nil
+}
+
+// FindOrphanedMembers returns the owners and assignees of tasks that are not
+// in the members table, ordered by task
+func (r *TaskRepositoryImpl) FindOrphanedMembers(ctx context.Context) ([]OrphanedMember, error) {
+	query := `
+		SELECT id, name, owner, 'owner' FROM tasks
+		WHERE owner NOT IN (SELECT name FROM members)
+		UNION ALL
+		SELECT t.id, t.name, a.member, a.role FROM task_assignees a
+		JOIN tasks t ON t.id = a.task_id
+		WHERE a.role != 'owner' AND a.member NOT IN (SELECT name FROM members)
+		ORDER BY 1, 3
+	`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned members: %v", err)
+	}
+	defer rows.Close()
+
+	var orphans []OrphanedMember
+	for rows.Next() {
+		var o OrphanedMember
+		if err := rows.Scan(&o.TaskID, &o.Task, &o.Member, &o.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan orphaned member: %v", err)
+		}
+		orphans = append(orphans, o)
+	}
+	return orphans, rows.Err()
+}
+
+// DeleteTask deletes a task with the given ID
+func (r *TaskRepositoryImpl) DeleteTask(ctx context.Context, id int) error {
+	query := `DELETE FROM tasks WHERE id = ?`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("Failed to delete task: %v", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to get affected rows: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return taskNotFound(id)
+	}
+
+	return nil
+}
+
+// AddComment adds a comment by the current member to a task
+func (r *TaskRepositoryImpl) AddComment(ctx context.Context, taskID int, body string) error {
+	if _, err := r.GetTaskById(ctx, taskID); err != nil {
+		return err
+	}
+
+	author, err := r.GetCurrentMember(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current member: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, "INSERT INTO task_comments (task_id, author, body) VALUES (?, ?, ?)", taskID, author, body)
+	if err != nil {
+		return fmt.Errorf("Failed to add comment: %v", err)
+	}
+
+	return nil
+}
+
+// GetComments returns the comments on a task, oldest first
+func (r *TaskRepositoryImpl) GetComments(ctx context.Context, taskID int) ([]Comment, error) {
+	query := `
+		SELECT id, task_id, author, body, created_at
+		FROM task_comments
+		WHERE task_id = ?
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %v", err)
+	}
+	defer rows.Close()
+
+	var comments []Comment
+	for rows.Next() {
+		var comment Comment
+		if err := rows.Scan(&comment.Id, &comment.TaskId, &comment.Author, &comment.Body, &comment.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %v", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+// ConnectToExternalDB connects to an external database
+func (r *TaskRepositoryImpl) ConnectToExternalDB(ctx context.Context, details ConnectionDetails) error {
+	logging.Debugf("Connecting to database...")
+
+	// Close existing database connection if any
+	if r.db != nil {
+		logging.Debugf("Closing existing database connection...")
+		if err := r.db.Close(); err != nil {
+			return fmt.Errorf("failed to close existing database connection: %v", err)
+		}
+	}
+
+	// For now, just use SQLite in file mode for demonstration
+	sqlitePath := "storage/tasks.db"
+	if details.Team != "" {
+		sqlitePath = fmt.Sprintf("storage/team_%s.db", details.Team)
+	}
+
+	// Ensure storage directory exists
+	logging.Debugf("Ensuring storage directory exists...")
+	if err := os.MkdirAll("storage", 0755); err != nil {
+		return fmt.Errorf("failed to create storage directory: %v", err)
+	}
+
+	logging.Debugf("Opening database connection to %s...", sqlitePath)
+	// Open the database connection
+	db, err := sql.Open("sqlite", sqlitePath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Test the connection
+	logging.Debugf("Testing database connection...")
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	// Set the connection in the repository
+	r.db = db
+
+	// Ensure required tables exist
+	logging.Debugf("Ensuring tables exist...")
+	if err := r.ensureTablesExist(ctx); err != nil {
+		return fmt.Errorf("failed to ensure tables exist: %v", err)
+	}
+
+	return nil
+}
+
+// ensureTablesExist checks if the necessary tables exist in the database
+// and creates them if they don't
+func (r *TaskRepositoryImpl) ensureTablesExist(ctx context.Context) error {
+	// Run each statement separately for better error handling
+	statements := []string{
+		// Create status table
+		`CREATE TABLE IF NOT EXISTS status (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE
+		)`,
+
+		// Create members table
+		`CREATE TABLE IF NOT EXISTS members (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			role TEXT NOT NULL DEFAULT 'member',
+			password_hash TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deactivated_at TIMESTAMP
+		)`,
+
+		// Create tasks table
+		`CREATE TABLE IF NOT EXISTS tasks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			owner TEXT NOT NULL,
+			collaborator TEXT,
+			priority INTEGER NOT NULL DEFAULT 0,
+			description TEXT NOT NULL DEFAULT '',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_by TEXT NOT NULL DEFAULT '',
+			due_date DATE,
+			completed_at TIMESTAMP,
+			FOREIGN KEY (status) REFERENCES status(id),
+			FOREIGN KEY (owner) REFERENCES members(name),
+			FOREIGN KEY (collaborator) REFERENCES members(name)
+		)`,
+
+		// Create task assignments table. Each member has one role per task.
+		`CREATE TABLE IF NOT EXISTS task_assignees (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			member TEXT NOT NULL,
+			role TEXT NOT NULL,
+			UNIQUE (task_id, member),
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (member) REFERENCES members(name)
+		)`,
+
+		// Create login sessions table. Only a hash of each token is stored;
+		// the token itself is kept by the member's IdentityStore.
+		`CREATE TABLE IF NOT EXISTS member_sessions (
+			token_hash TEXT PRIMARY KEY,
+			member TEXT NOT NULL,
+			expires_at TIMESTAMP NOT NULL,
+			FOREIGN KEY (member) REFERENCES members(name)
+		)`,
+
+		// Create task comments table
+		`CREATE TABLE IF NOT EXISTS task_comments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+			FOREIGN KEY (author) REFERENCES members(name)
+		)`,
+
+		// Remove comments together with their task
+		`CREATE TRIGGER IF NOT EXISTS tasks_delete_comments AFTER DELETE ON tasks BEGIN
+			DELETE FROM task_comments WHERE task_id = old.id;
+		END`,
+
+		// Remove assignments together with their task
+		`CREATE TRIGGER IF NOT EXISTS tasks_delete_assignees AFTER DELETE ON tasks BEGIN
+			DELETE FROM task_assignees WHERE task_id = old.id;
+		END`,
+
+		// Create task history table. Each row is a change to a task, e.g. a
+		// status event whose value is the new status.
+		`CREATE TABLE IF NOT EXISTS task_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			task_id INTEGER NOT NULL,
+			type TEXT NOT NULL,
+			value TEXT NOT NULL DEFAULT '',
+			actor TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+		)`,
+
+		// Remove the history together with its task
+		`CREATE TRIGGER IF NOT EXISTS tasks_delete_events AFTER DELETE ON tasks BEGIN
+			DELETE FROM task_events WHERE task_id = old.id;
+		END`,
+
+		// Add default statuses if not exist. in_progress and blocked were
+		// added later, so their IDs depend on the database; see GetStatuses
+		// for the order.
+		`INSERT OR IGNORE INTO status (id, name) VALUES (1, 'pending'), (2, 'done')`,
+		`INSERT OR IGNORE INTO status (name) VALUES ('in_progress')`,
+		`INSERT OR IGNORE INTO status (name) VALUES ('blocked')`,
+	}
+
+	// Tasks used to have a single collaborator column; copy the owners and
+	// collaborators into the assignments table when it is first created
+	migrateAssignees, err := r.tableMissing(ctx, "task_assignees")
+	if err != nil {
+		return err
+	}
+	// The history starts with what can be told from the tasks themselves
+	migrateEvents, err := r.tableMissing(ctx, "task_events")
+	if err != nil {
+		return err
+	}
+	// The current member is kept per user, see IdentityStore. Databases of
+	// older versions kept it in the current_member table, which new ones
+	// don't have.
+	noCurrentMember, err := r.tableMissing(ctx, "current_member")
+	if err != nil {
+		return err
+	}
+
+	// Execute each SQL statement
+	for _, stmt := range statements {
+		_, err := r.db.ExecContext(ctx, stmt)
+		if err != nil {
+			return fmt.Errorf("error executing SQL statement: %v\nStatement: %s", err, stmt)
+		}
+	}
+
+	// Upgrade tables created by older versions
+	if err := r.ensureColumn(ctx, "tasks", "priority", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "tasks", "description", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "members", "role", "TEXT NOT NULL DEFAULT 'member'"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "members", "password_hash", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "members", "deactivated_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	// SQLite can't add a column with a CURRENT_TIMESTAMP default, so
+	// updated_at is NULL for tasks not changed since the upgrade
+	if err := r.ensureColumn(ctx, "tasks", "updated_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "tasks", "updated_by", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "tasks", "due_date", "DATE"); err != nil {
+		return err
+	}
+	if err := r.ensureColumn(ctx, "tasks", "completed_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	// Tasks done before completed_at was recorded were most likely
+	// completed by their last change
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE tasks SET completed_at = COALESCE(updated_at, created_at)
+		WHERE completed_at IS NULL AND status = (SELECT id FROM status WHERE name = 'done')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to set completion times: %v", err)
+	}
+
+	// A database needs an admin to manage members. Members added before
+	// there were roles are all members, so the current member, or else the
+	// earliest one, becomes admin.
+	admin := "(SELECT MIN(id) FROM members)"
+	if !noCurrentMember {
+		admin = "COALESCE((SELECT m.id FROM members m JOIN current_member c ON c.member_name = m.name), " + admin + ")"
+	}
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE members SET role = 'admin'
+		WHERE id = `+admin+`
+		AND NOT EXISTS (SELECT 1 FROM members WHERE role = 'admin')
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to set up member roles: %v", err)
+	}
+	if !noCurrentMember {
+		if err := r.adoptCurrentMember(ctx); err != nil {
+			return err
+		}
+	}
+
+	if migrateAssignees {
+		logging.Debugf("Moving owners and collaborators to task_assignees...")
+		if err := r.migrateCollaborators(ctx); err != nil {
+			return err
+		}
+	}
+	if migrateEvents {
+		logging.Debugf("Adding the history of existing tasks to task_events...")
+		if err := r.migrateEvents(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Indexes used by sorted and paginated queries
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status)`,
+		`CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority)`,
+		`CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date)`,
+		`CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_task_assignees_member ON task_assignees(member)`,
+		`CREATE INDEX IF NOT EXISTS idx_task_events_task_id ON task_events(task_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_task_events_created_at ON task_events(created_at)`,
+	}
+	for _, stmt := range indexes {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("error executing SQL statement: %v\nStatement: %s", err, stmt)
+		}
+	}
+
+	// The search index is optional; searches fall back to LIKE without it
+	r.fts = r.ensureSearchIndex(ctx) == nil
+
+	return nil
+}
+
+// tableMissing reports whether a table doesn't exist yet
+func (r *TaskRepositoryImpl) tableMissing(ctx context.Context, table string) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?", table).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check if %s table exists: %v", table, err)
+	}
+	return count == 0, nil
+}
+
+// migrateCollaborators assigns the owner and the collaborator of every task
+// from the old columns. The collaborator column is left as it is but no
+// longer used.
+func (r *TaskRepositoryImpl) migrateCollaborators(ctx context.Context) error {
+	statements := []string{
+		`INSERT OR IGNORE INTO task_assignees (task_id, member, role)
+			SELECT id, owner, 'owner' FROM tasks ORDER BY id`,
+		`INSERT OR IGNORE INTO task_assignees (task_id, member, role)
+			SELECT id, collaborator, 'assignee' FROM tasks
+			WHERE IFNULL(collaborator, '') != '' ORDER BY id`,
+	}
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate collaborators: %v", err)
+		}
+	}
+	return nil
+}
+
+// migrateEvents records when existing tasks were created and completed, the
+// only history older versions kept
+func (r *TaskRepositoryImpl) migrateEvents(ctx context.Context) error {
+	statements := []string{
+		`INSERT INTO task_events (task_id, type, value, actor, created_at)
+			SELECT id, 'created', '', owner, created_at FROM tasks ORDER BY id`,
+		`INSERT INTO task_events (task_id, type, value, actor, created_at)
+			SELECT id, 'status', 'done', IFNULL(updated_by, ''), completed_at FROM tasks
+			WHERE completed_at IS NOT NULL ORDER BY completed_at`,
+	}
+	for _, stmt := range statements {
+		if _, err := r.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to migrate task history: %v", err)
+		}
+	}
+	return nil
+}
+
+// ensureColumn adds a column to a table if it doesn't exist yet
+func (r *TaskRepositoryImpl) ensureColumn(ctx context.Context, table, column, definition string) error {
+	exists, err := r.hasColumn(ctx, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s to %s: %v", column, table, err)
+	}
+
+	return nil
+}
+
+// hasColumn reports whether a table has a column
+func (r *TaskRepositoryImpl) hasColumn(ctx context.Context, table, column string) (bool, error) {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid          int
+			name, ctype  string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &defaultValue, &pk); err != nil {
+			return false, fmt.Errorf("failed to inspect table %s: %v", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %v", table, err)
+	}
+	return false, nil
+}
+
+// EnsureSchema creates missing tables and upgrades existing ones
+func (r *TaskRepositoryImpl) EnsureSchema(ctx context.Context) error {
+	return r.ensureTablesExist(ctx)
+}
+
+// SetupMemberTable ensures the members table exists
+func (r *TaskRepositoryImpl) SetupMemberTable(ctx context.Context) error {
+	logging.Debugf("Setting up member table...")
+
+	// Check if the tables exist first
+	var tableExists int
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='members'").Scan(&tableExists)
+	if err != nil {
+		return fmt.Errorf("failed to check if members table exists: %v", err)
+	}
+
+	if tableExists == 0 {
+		logging.Debugf("Members table does not exist, creating it...")
+		_, err := r.db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS members (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				name TEXT NOT NULL UNIQUE,
+				role TEXT NOT NULL DEFAULT 'member',
+				password_hash TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				deactivated_at TIMESTAMP
+			)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to create members table: %v", err)
+		}
+	}
+
+	// Check if we have any members
+	var count int
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM members").Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check members table: %v", err)
+	}
+
+	logging.Debugf("Member setup complete. Members: %d", count)
+	return nil
+}
+
+// GetCurrentMember returns the name of the current member, which is kept in
+// the local identity store rather than in the shared database
+func (r *TaskRepositoryImpl) GetCurrentMember(ctx context.Context) (string, error) {
+	logging.Debugf("Getting current member...")
+
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	name, err := r.identities.Identity(database)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", ErrNoCurrentMember
+	}
+
+	// Members with a password need a login session that hasn't expired
+	hash, err := r.GetPasswordHash(ctx, name)
+	if err != nil && !errors.Is(err, ErrUnknownMember) {
+		return "", err
+	}
+	if hash != "" {
+		token, err := r.identities.Session(database)
+		if err != nil {
+			return "", err
+		}
+		var count int
+		err = r.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM member_sessions
+			WHERE token_hash = ? AND member = ? AND expires_at > datetime('now')
+		`, hashToken(token), name).Scan(&count)
+		if err != nil {
+			return "", fmt.Errorf("failed to check login session: %v", err)
+		}
+		if token == "" || count == 0 {
+			return "", fmt.Errorf("%w: %s has a password and must log in", ErrLoginRequired, name)
+		}
+	}
+
+	logging.Debugf("Current member is: %s", name)
+	return name, nil
+}
+
+// actor returns the authenticated member that writes are recorded for
+func (r *TaskRepositoryImpl) actor(ctx context.Context) (string, error) {
+	name, err := r.GetCurrentMember(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current member: %w", err)
+	}
+	return name, nil
+}
+
+// SetCurrentMember sets the current member for the local user, adding the
+// member if they don't exist yet
+func (r *TaskRepositoryImpl) SetCurrentMember(ctx context.Context, name string) error {
+	logging.Debugf("Setting current member to: %s", name)
+
+	// First, ensure the member exists
+	if err := r.AddMember(ctx, name); err != nil {
+		return err
+	}
+
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.identities.SetIdentity(database, name); err != nil {
+		return err
+	}
+	// A login session belongs to the previous member
+	if err := r.identities.SetSession(database, ""); err != nil {
+		return err
+	}
+
+	logging.Debugf("Current member set to: %s", name)
+	return nil
+}
+
+// StartSession makes name the current member with a new login session that
+// expires after ttl, and returns when it expires. The caller checks the
+// member's password first.
+func (r *TaskRepositoryImpl) StartSession(ctx context.Context, name string, ttl time.Duration) (time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Time{}, fmt.Errorf("failed to create session token: %v", err)
+	}
+	token := hex.EncodeToString(buf)
+	expires := time.Now().Add(ttl).UTC()
+
+	// Expired sessions are of no use to anyone
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM member_sessions WHERE expires_at <= datetime('now')"); err != nil {
+		return time.Time{}, fmt.Errorf("failed to remove expired sessions: %v", err)
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO member_sessions (token_hash, member, expires_at) VALUES (?, ?, ?)",
+		hashToken(token), name, expires.Format(time.DateTime))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to create session: %v", err)
+	}
+
+	if err := r.SetCurrentMember(ctx, name); err != nil {
+		return time.Time{}, err
+	}
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := r.identities.SetSession(database, token); err != nil {
+		return time.Time{}, err
+	}
+	return expires, nil
+}
+
+// EndSession removes the local user's login session, if any
+func (r *TaskRepositoryImpl) EndSession(ctx context.Context) error {
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return err
+	}
+	token, err := r.identities.Session(database)
+	if err != nil || token == "" {
+		return err
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM member_sessions WHERE token_hash = ?", hashToken(token)); err != nil {
+		return fmt.Errorf("failed to remove session: %v", err)
+	}
+	return r.identities.SetSession(database, "")
+}
+
+// hashToken returns the hash of a session token that is stored in the
+// database
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// databaseKey identifies the connected database in the identity store by the
+// absolute path of its file
+func (r *TaskRepositoryImpl) databaseKey(ctx context.Context) (string, error) {
+	var file string
+	err := r.db.QueryRowContext(ctx, "SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file)
+	if err != nil {
+		return "", fmt.Errorf("failed to get database file: %v", err)
+	}
+	return file, nil
+}
+
+// adoptCurrentMember moves the current member that older versions stored in
+// the shared current_member table to the local identity store, unless the
+// local user already has an identity for this database. The row is removed so
+// that nobody else picks it up.
+func (r *TaskRepositoryImpl) adoptCurrentMember(ctx context.Context) error {
+	var name string
+	err := r.db.QueryRowContext(ctx, "SELECT member_name FROM current_member LIMIT 1").Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get current member: %v", err)
+	}
+
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := r.identities.Identity(database)
+	if err != nil {
+		return err
+	}
+	if current == "" {
+		logging.Debugf("Moving current member %s to the local identity store...", name)
+		if err := r.identities.SetIdentity(database, name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM current_member"); err != nil {
+		return fmt.Errorf("failed to clear current member: %v", err)
+	}
+	return nil
+}
+
+// GetStatuses returns the status names in workflow order: by ID, except
+// that done comes last
+func (r *TaskRepositoryImpl) GetStatuses(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name FROM status ORDER BY name = 'done', id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statuses: %v", err)
+	}
+	defer rows.Close()
+
+	var statuses []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan status: %v", err)
+		}
+		statuses = append(statuses, name)
+	}
+
+	return statuses, rows.Err()
+}
+
+// GetAllMembers returns all members
+func (r *TaskRepositoryImpl) GetAllMembers(ctx context.Context) ([]Member, error) {
+	query := "SELECT id, name, role, created_at, IFNULL(strftime('%Y-%m-%dT%H:%M:%SZ', deactivated_at), '') FROM members ORDER BY name"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query members: %v", err)
+	}
+	defer rows.Close()
 
 	var members []Member
 	for rows.Next() {
 		var member Member
-		if err := rows.Scan(&member.Id, &member.Name, &member.CreatedAt); err != nil {
+		if err := rows.Scan(&member.Id, &member.Name, &member.Role, &member.CreatedAt, &member.DeactivatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan member: %v", err)
 		}
 		members = append(members, member)
@@ -502,24 +1547,323 @@ func (r *TaskRepositoryImpl) GetAllMembers() ([]Member, error) {
 	return members, nil
 }
 
+// GetMember returns the member with the given name. Returns ErrUnknownMember
+// when there is none.
+func (r *TaskRepositoryImpl) GetMember(ctx context.Context, name string) (*Member, error) {
+	var member Member
+	err := r.db.QueryRowContext(ctx, "SELECT id, name, role, created_at, IFNULL(strftime('%Y-%m-%dT%H:%M:%SZ', deactivated_at), '') FROM members WHERE name = ?", name).
+		Scan(&member.Id, &member.Name, &member.Role, &member.CreatedAt, &member.DeactivatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member: %v", err)
+	}
+	return &member, nil
+}
+
+// GetPasswordHash returns the password hash of a member, or "" when they
+// have no password
+func (r *TaskRepositoryImpl) GetPasswordHash(ctx context.Context, name string) (string, error) {
+	var hash string
+	err := r.db.QueryRowContext(ctx, "SELECT password_hash FROM members WHERE name = ?", name).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get password: %v", err)
+	}
+	return hash, nil
+}
+
+// SetPasswordHash sets the password hash of a member. An empty hash removes
+// the password. Changing the password ends the member's sessions.
+func (r *TaskRepositoryImpl) SetPasswordHash(ctx context.Context, name string, hash string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE members SET password_hash = ? WHERE name = ?", hash, name)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set password: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM member_sessions WHERE member = ?", name); err != nil {
+		return fmt.Errorf("failed to end sessions: %v", err)
+	}
+	return nil
+}
+
+// SetMemberRole changes the role of a member
+func (r *TaskRepositoryImpl) SetMemberRole(ctx context.Context, name string, role string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE members SET role = ? WHERE name = ?", role, name)
+	if err != nil {
+		return fmt.Errorf("failed to set member role: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set member role: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+	return nil
+}
+
+// RenameMember renames a member. Tasks, assignments, comments and sessions
+// refer to members by name, so they are renamed too, as is the local user's
+// identity. Other users who are the member must switch to the new name.
+func (r *TaskRepositoryImpl) RenameMember(ctx context.Context, name string, newName string) error {
+	// Columns that hold member names. The deleted, completed and archived
+	// columns only exist in databases upgraded with migration 0003.
+	references := [][2]string{
+		{"members", "name"},
+		{"tasks", "owner"},
+		{"tasks", "collaborator"},
+		{"tasks", "updated_by"},
+		{"tasks", "deleted_by"},
+		{"tasks", "completed_by"},
+		{"tasks", "archived_by"},
+		{"task_assignees", "member"},
+		{"task_comments", "author"},
+		{"task_events", "actor"},
+		{"member_sessions", "member"},
+		{"current_member", "member_name"},
+	}
+
+	var statements []string
+	for _, ref := range references {
+		exists, err := r.hasColumn(ctx, ref[0], ref[1])
+		if err != nil {
+			return err
+		}
+		if exists {
+			statements = append(statements, fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", ref[0], ref[1], ref[1]))
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		_, err = tx.ExecContext(ctx, stmt, newName, name)
+		if err != nil {
+			return fmt.Errorf("failed to rename member: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to rename member: %v", err)
+	}
+
+	database, err := r.databaseKey(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := r.identities.Identity(database)
+	if err != nil {
+		return err
+	}
+	if current == name {
+		return r.identities.SetIdentity(database, newName)
+	}
+	return nil
+}
+
+// openTasks selects the IDs of the tasks that aren't done
+const openTasks = "SELECT id FROM tasks WHERE status != (SELECT id FROM status WHERE name = 'done')"
+
+// GetMemberUsage counts the tasks a member is assigned to and the changes
+// they made
+func (r *TaskRepositoryImpl) GetMemberUsage(ctx context.Context, name string) (*MemberUsage, error) {
+	var usage MemberUsage
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(DISTINCT task_id) FROM task_assignees WHERE member = ?),
+			(SELECT COUNT(DISTINCT task_id) FROM task_assignees WHERE member = ? AND task_id IN (`+openTasks+`)),
+			(SELECT COUNT(*) FROM tasks WHERE updated_by = ?) + (SELECT COUNT(*) FROM task_comments WHERE author = ?) +
+			(SELECT COUNT(*) FROM task_events WHERE actor = ?)
+	`, name, name, name, name, name).Scan(&usage.Tasks, &usage.OpenTasks, &usage.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count member tasks: %v", err)
+	}
+	return &usage, nil
+}
+
+// reassignTasks hands the tasks selected by taskIDs from one member to
+// another. The new member takes over each role, except on tasks they are
+// already assigned to, where they keep their own role unless they take
+// over as owner. Returns the number of tasks changed.
+func reassignTasks(ctx context.Context, tx *sql.Tx, from, to, taskIDs, actor string) (int, error) {
+	var count int
+	err := tx.QueryRowContext(ctx, "SELECT COUNT(DISTINCT task_id) FROM task_assignees WHERE member = ? AND task_id IN ("+taskIDs+")", from).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tasks: %v", err)
+	}
+
+	statements := []string{
+		`UPDATE tasks SET updated_at = CURRENT_TIMESTAMP, updated_by = ?3
+			WHERE id IN (SELECT task_id FROM task_assignees WHERE member = ?1) AND id IN (` + taskIDs + `)`,
+		`DELETE FROM task_assignees WHERE member = ?2 AND task_id IN (
+			SELECT task_id FROM task_assignees WHERE member = ?1 AND role = 'owner' AND task_id IN (` + taskIDs + `))`,
+		`DELETE FROM task_assignees WHERE member = ?1 AND task_id IN (` + taskIDs + `)
+			AND task_id IN (SELECT task_id FROM task_assignees WHERE member = ?2)`,
+		`UPDATE task_assignees SET member = ?2 WHERE member = ?1 AND task_id IN (` + taskIDs + `)`,
+		`UPDATE tasks SET owner = ?2 WHERE owner = ?1 AND id IN (` + taskIDs + `)`,
+		`UPDATE tasks SET collaborator = ?2 WHERE collaborator = ?1 AND id IN (` + taskIDs + `)`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, from, to, actor); err != nil {
+			return 0, fmt.Errorf("failed to reassign tasks: %v", err)
+		}
+	}
+	return count, nil
+}
+
+// DeactivateMember marks a member as deactivated and ends their sessions.
+// When reassignTo is set, their open tasks are reassigned to that member
+// first. Returns the number of tasks reassigned.
+func (r *TaskRepositoryImpl) DeactivateMember(ctx context.Context, name string, reassignTo string) (int, error) {
+	actor, err := r.actor(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	reassigned := 0
+	if reassignTo != "" {
+		if reassigned, err = reassignTasks(ctx, tx, name, reassignTo, openTasks, actor); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE members SET deactivated_at = CURRENT_TIMESTAMP WHERE name = ?", name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate member: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to deactivate member: %v", err)
+	}
+	if rowsAffected == 0 {
+		return 0, fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM member_sessions WHERE member = ?", name); err != nil {
+		return 0, fmt.Errorf("failed to end sessions: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to deactivate member: %v", err)
+	}
+	return reassigned, nil
+}
+
+// ActivateMember undoes DeactivateMember. Tasks that were reassigned stay
+// with their new members.
+func (r *TaskRepositoryImpl) ActivateMember(ctx context.Context, name string) error {
+	res, err := r.db.ExecContext(ctx, "UPDATE members SET deactivated_at = NULL WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to activate member: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to activate member: %v", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+	return nil
+}
+
+// RemoveMember deletes a member. When reassignTo is set, all their tasks are
+// reassigned to that member first; otherwise they are unassigned from the
+// tasks they don't own. Returns the number of tasks reassigned.
+func (r *TaskRepositoryImpl) RemoveMember(ctx context.Context, name string, reassignTo string) (int, error) {
+	actor, err := r.actor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	noCurrentMember, err := r.tableMissing(ctx, "current_member")
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	reassigned := 0
+	if reassignTo != "" {
+		if reassigned, err = reassignTasks(ctx, tx, name, reassignTo, "SELECT id FROM tasks", actor); err != nil {
+			return 0, err
+		}
+	}
+
+	statements := []string{
+		"DELETE FROM task_assignees WHERE member = ? AND role != 'owner'",
+		"DELETE FROM member_sessions WHERE member = ?",
+	}
+	if !noCurrentMember {
+		statements = append(statements, "DELETE FROM current_member WHERE member_name = ?")
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, name); err != nil {
+			return 0, fmt.Errorf("failed to remove member: %v", err)
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM members WHERE name = ?", name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove member: %v", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove member: %v", err)
+	}
+	if rowsAffected == 0 {
+		return 0, fmt.Errorf("%w %q", ErrUnknownMember, name)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to remove member: %v", err)
+	}
+	return reassigned, nil
+}
+
 // AddMember adds a new member if they don't already exist
-func (r *TaskRepositoryImpl) AddMember(name string) error {
-	fmt.Printf("Adding member: %s\n", name)
+func (r *TaskRepositoryImpl) AddMember(ctx context.Context, name string) error {
+	logging.Debugf("Adding member: %s", name)
 
 	// Check if the table exists first
 	var tableExists int
-	err := r.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name='members'").Scan(&tableExists)
+	err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type='table' AND name='members'").Scan(&tableExists)
 	if err != nil {
 		return fmt.Errorf("failed to check if members table exists: %v", err)
 	}
 
 	if tableExists == 0 {
-		fmt.Println("Members table does not exist, creating it...")
-		_, err := r.db.Exec(`
+		logging.Debugf("Members table does not exist, creating it...")
+		_, err := r.db.ExecContext(ctx, `
 			CREATE TABLE IF NOT EXISTS members (
 				id INTEGER PRIMARY KEY AUTOINCREMENT,
 				name TEXT NOT NULL UNIQUE,
-				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+				role TEXT NOT NULL DEFAULT 'member',
+				password_hash TEXT NOT NULL DEFAULT '',
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				deactivated_at TIMESTAMP
 			)
 		`)
 		if err != nil {
@@ -533,21 +1877,25 @@ func (r *TaskRepositoryImpl) AddMember(name string) error {
 
 	// Check if member already exists
 	var count int
-	err = r.db.QueryRow("SELECT COUNT(*) FROM members WHERE name = ?", name).Scan(&count)
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM members WHERE name = ?", name).Scan(&count)
 	if err != nil {
 		return fmt.Errorf("failed to check if member exists: %v", err)
 	}
 
 	// If member doesn't exist, add them
 	if count == 0 {
-		fmt.Printf("Member %s does not exist, adding...\n", name)
-		_, err = r.db.Exec("INSERT INTO members (name) VALUES (?)", name)
+		logging.Debugf("Member %s does not exist, adding...", name)
+		// The first member of a database is its admin
+		_, err = r.db.ExecContext(ctx, `
+			INSERT INTO members (name, role)
+			SELECT ?, CASE WHEN EXISTS (SELECT 1 FROM members WHERE role = 'admin') THEN 'member' ELSE 'admin' END
+		`, name)
 		if err != nil {
 			return fmt.Errorf("failed to add member: %v", err)
 		}
-		fmt.Printf("Member %s added successfully\n", name)
+		logging.Debugf("Member %s added successfully", name)
 	} else {
-		fmt.Printf("Member %s already exists\n", name)
+		logging.Debugf("Member %s already exists", name)
 	}
 
 	return nil
@@ -1,14 +1,25 @@
 package task
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Login sessions last DefaultSessionTTL unless another duration is asked for,
+// up to MaxSessionTTL
+const (
+	DefaultSessionTTL = 8 * time.Hour
+	MaxSessionTTL     = 24 * time.Hour
 )
 
+// minPasswordLength is the shortest password SetPassword accepts
+const minPasswordLength = 8
+
 type TaskServiceImpl struct {
 	repo TaskRepository
 }
@@ -26,399 +37,1075 @@ func (s *TaskServiceImpl) ensureConnect() error {
 	return nil
 }
 
-func (s *TaskServiceImpl) PromptForUsername() error {
-	reader := bufio.NewReader(os.Stdin)
+// AddTask validates and adds a task owned by the current member. Assignees
+// must be members; unknown names return ErrUnknownMember.
+func (s *TaskServiceImpl) AddTask(ctx context.Context, data NewTaskSchema) (*Task, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
+	}
 
-	fmt.Print("Enter your name: ")
-	name, err := reader.ReadString('\n')
+	if !data.Validate() {
+		return nil, invalidInput("task name cannot be empty")
+	}
+	dueDate, err := ParseDueDate(data.DueDate, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to read input: %v", err)
+		return nil, invalidInput("%w", err)
+	}
+	if _, err := s.canEdit(ctx); err != nil {
+		return nil, err
 	}
 
-	// Trim whitespace and newlines
-	name = strings.TrimSpace(name)
+	members := append([]string(nil), data.Assignees...)
+	if data.Collaborator != "" {
+		members = append(members, data.Collaborator)
+	}
+	members, err = s.resolveMembers(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMembers(ctx, members); err != nil {
+		return nil, err
+	}
+	var assignees []Assignee
+	for _, member := range members {
+		assignees = append(assignees, Assignee{Member: member, Role: RoleAssignee})
+	}
 
-	if name == "" {
-		return fmt.Errorf("name cannot be empty")
+	return s.repo.AddTask(ctx, Task{
+		Name:        data.Name,
+		Assignees:   assignees,
+		Priority:    data.Priority,
+		Description: data.Description,
+		DueDate:     dueDate,
+	})
+}
+
+// ListTasks returns the tasks selected by opts. By default only open tasks,
+// i.e. tasks that aren't done, are returned; opts.Completed restricts the list to done tasks and opts.All
+// returns every task. opts.Filter is an optional filter expression, see
+// ParseFilter.
+func (s *TaskServiceImpl) ListTasks(ctx context.Context, opts ListOptions) ([]Task, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
+	}
+
+	filter, err := ParseFilter(opts.Filter)
+	if err != nil {
+		return nil, invalidInput("invalid filter: %w", err)
+	}
+	if opts.Assignee != "" {
+		filter.Add("assignee", OpEqual, opts.Assignee)
 	}
 
-	// Set as current member
-	return s.repo.SetCurrentMember(name)
+	// An explicit status in the filter takes precedence over the default
+	if !filter.HasField("status") {
+		if opts.Completed {
+			filter.Add("status", OpEqual, StatusDone)
+		} else if !opts.All {
+			filter.Add("status", OpNotEqual, StatusDone)
+		}
+	}
+
+	if filter.usesMe() {
+		member, err := s.repo.GetCurrentMember(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot resolve 'me': %w", err)
+		}
+		filter.ResolveMember(member)
+	}
+
+	sort, err := ParseSort(opts.Sort)
+	if err != nil {
+		return nil, invalidInput("invalid sort: %w", err)
+	}
+
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return nil, invalidInput("limit and offset cannot be negative")
+	}
+
+	return s.repo.QueryTasks(ctx, TaskQuery{
+		Filter: filter,
+		Sort:   sort,
+		Limit:  opts.Limit,
+		Offset: opts.Offset,
+	})
 }
 
-func (s *TaskServiceImpl) HandleSetupMember() error {
+// GetTask returns a task with its comments
+func (s *TaskServiceImpl) GetTask(ctx context.Context, id int) (*TaskDetail, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	// Try to get current member
-	_, err := s.repo.GetCurrentMember()
+	task, err := s.repo.GetTaskById(ctx, id)
 	if err != nil {
-		// If no current member, prompt for username
-		fmt.Println("No username set for this database. Let's set one up.")
-		return s.PromptForUsername()
+		return nil, err
 	}
 
-	return nil
+	comments, err := s.repo.GetComments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if comments == nil {
+		comments = []Comment{}
+	}
+
+	history, err := s.repo.GetTaskEvents(ctx, EventQuery{TaskID: id})
+	if err != nil {
+		return nil, err
+	}
+	if history == nil {
+		history = []TaskEvent{}
+	}
+
+	return &TaskDetail{Task: *task, Comments: comments, History: history}, nil
 }
 
-func (s *TaskServiceImpl) HandleAddTask(name, collaborator string) error {
+// CompleteTask marks a task as done and returns it
+func (s *TaskServiceImpl) CompleteTask(ctx context.Context, id int) (*Task, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.repo.AddTask(Task{
-		Name:         name,
-		Collaborator: collaborator,
-	})
+	if _, err := s.canEdit(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.repo.DoneTask(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetTaskById(ctx, id)
 }
 
-func (s *TaskServiceImpl) HandleListTasks() error {
+// UpdateTask applies the fields set in data to a task and returns the result.
+// Empty strings and nil pointers leave the current value unchanged.
+func (s *TaskServiceImpl) UpdateTask(ctx context.Context, data UpdateTaskSchema) (*Task, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	tasks, err := s.repo.GetTask()
-	if err != nil {
-		return err
+	if _, err := s.repo.GetTaskById(ctx, data.ID); err != nil {
+		return nil, err
+	}
+	var dueDate string
+	if data.DueDate != nil {
+		var err error
+		if dueDate, err = ParseDueDate(*data.DueDate, time.Now()); err != nil {
+			return nil, invalidInput("%w", err)
+		}
+	}
+	if _, err := s.canEdit(ctx); err != nil {
+		return nil, err
 	}
 
-	if len(tasks) == 0 {
-		fmt.Println("No tasks found.")
-		return nil
+	// Determine the status based on the Completed field. An empty status
+	// keeps the current one.
+	status := data.Status
+	if data.Completed != nil && *data.Completed {
+		status = StatusDone
+	}
+	if status != "" {
+		statuses, err := s.repo.GetStatuses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !containsString(statuses, status) {
+			return nil, invalidInput("unknown status %q: expected one of %s", status, strings.Join(statuses, ", "))
+		}
 	}
 
-	fmt.Println("Tasks:")
-	for _, task := range tasks {
-		collaboratorInfo := ""
-		if task.Collaborator != "" {
-			collaboratorInfo = fmt.Sprintf(" (Collaborator: %s)", task.Collaborator)
+	if data.Name != "" || status != "" {
+		if err := s.repo.UpdateTask(ctx, data.ID, data.Name, status); err != nil {
+			return nil, err
 		}
-		fmt.Printf("- ID: %d, Name: %s, Status: %s, Owner: %s%s\n", task.Id, task.Name, task.Status, task.Owner, collaboratorInfo)
 	}
 
-	return nil
+	if data.Collaborator != "" {
+		if _, err := s.AssignTask(ctx, data.ID, RoleAssignee, []string{data.Collaborator}); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Priority != nil {
+		if err := s.repo.SetTaskPriority(ctx, data.ID, *data.Priority); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.Description != nil {
+		if err := s.repo.SetTaskDescription(ctx, data.ID, *data.Description); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.DueDate != nil {
+		if err := s.repo.SetTaskDueDate(ctx, data.ID, dueDate); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.GetTaskById(ctx, data.ID)
 }
 
-func (s *TaskServiceImpl) HandleUpdateTask(id, name, status, collaborator string) error {
+// DeleteTask deletes a task and its comments. Only the task's owner and
+// admins may delete it.
+func (s *TaskServiceImpl) DeleteTask(ctx context.Context, id int) error {
 	if err := s.ensureConnect(); err != nil {
 		return err
 	}
 
-	idInt, err := strconv.Atoi(id)
+	task, err := s.repo.GetTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+	member, err := s.canEdit(ctx)
 	if err != nil {
-		return fmt.Errorf("Invalid ID: %s", id)
+		return err
+	}
+	if member.Role != MemberRoleAdmin && member.Name != task.Owner {
+		return permissionDenied("only %s, who owns task %d, or an admin can delete it", task.Owner, id)
 	}
 
-	return s.repo.UpdateTask(idInt, name, status, collaborator)
+	return s.repo.DeleteTask(ctx, id)
 }
 
-func (s *TaskServiceImpl) HandleGetTask(id string) error {
+// SearchTasks returns the tasks matching a full-text search, best match first.
+// Matched terms are wrapped in HighlightStart and HighlightEnd.
+func (s *TaskServiceImpl) SearchTasks(ctx context.Context, query string) ([]SearchResult, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	idInt, err := strconv.Atoi(id)
-	if err != nil {
-		return fmt.Errorf("Invalid ID: %s", id)
+	return s.repo.SearchTasks(ctx, query)
+}
+
+// AddComment adds a comment by the current member to a task
+func (s *TaskServiceImpl) AddComment(ctx context.Context, id int, body string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
 	}
 
-	task, err := s.repo.GetTaskById(idInt)
-	if err != nil {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return invalidInput("comment cannot be empty")
+	}
+	if _, err := s.canEdit(ctx); err != nil {
 		return err
 	}
 
-	collaboratorInfo := ""
-	if task.Collaborator != "" {
-		collaboratorInfo = fmt.Sprintf("Collaborator: %s\n", task.Collaborator)
+	return s.repo.AddComment(ctx, id, body)
+}
+
+// AssignTask gives members a role on a task and returns the task. "me" is
+// the current member and unknown names return ErrUnknownMember. Only the
+// task's owner and admins may assign the owner role. See
+// TaskRepository.AssignMembers.
+func (s *TaskServiceImpl) AssignTask(ctx context.Context, id int, role string, members []string) (*Task, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Task Details:\n")
-	fmt.Printf("ID: %d\n", task.Id)
-	fmt.Printf("Name: %s\n", task.Name)
-	fmt.Printf("Status: %s\n", task.Status)
-	fmt.Printf("Created At: %s\n", task.CreatedAt)
-	fmt.Printf("Owner: %s\n", task.Owner)
-	fmt.Printf("%s", collaboratorInfo)
+	role, err := ParseRole(role)
+	if err != nil {
+		return nil, invalidInput("%w", err)
+	}
+	member, err := s.canEdit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Owners may delete their tasks, so ownership is only given away by the
+	// owner or an admin
+	if role == RoleOwner && member.Role != MemberRoleAdmin {
+		task, err := s.repo.GetTaskById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if member.Name != task.Owner {
+			return nil, permissionDenied("only %s, who owns task %d, or an admin can change its owner", task.Owner, id)
+		}
+	}
+	members, err = s.resolveMembers(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, invalidInput("no members to assign")
+	}
+	if err := s.checkMembers(ctx, members); err != nil {
+		return nil, err
+	}
 
-	return nil
+	if err := s.repo.AssignMembers(ctx, id, role, members); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTaskById(ctx, id)
 }
 
-func (s *TaskServiceImpl) HandleListMembers() error {
+// UnassignTask removes members from a task and returns the task. "me" is the
+// current member.
+func (s *TaskServiceImpl) UnassignTask(ctx context.Context, id int, members []string) (*Task, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	members, err := s.repo.GetAllMembers()
+	members, err := s.resolveMembers(ctx, members)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
 	if len(members) == 0 {
-		fmt.Println("No members found.")
-		return nil
+		return nil, invalidInput("no members to unassign")
+	}
+	if _, err := s.canEdit(ctx); err != nil {
+		return nil, err
 	}
 
-	// Get current member for highlighting
-	currentMember, err := s.repo.GetCurrentMember()
-	if err == nil {
-		fmt.Printf("Current user: %s\n\n", currentMember)
+	if err := s.repo.UnassignMembers(ctx, id, members); err != nil {
+		return nil, err
 	}
+	return s.repo.GetTaskById(ctx, id)
+}
 
-	fmt.Println("Members:")
+// resolveMembers trims member names, drops empty ones and duplicates, and
+// replaces "me" with the current member
+func (s *TaskServiceImpl) resolveMembers(ctx context.Context, members []string) ([]string, error) {
+	var resolved []string
 	for _, member := range members {
-		isCurrent := ""
-		if currentMember == member.Name {
-			isCurrent = " (you)"
+		member = strings.TrimSpace(member)
+		if member == "me" {
+			current, err := s.repo.GetCurrentMember(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve 'me': %w", err)
+			}
+			member = current
 		}
-		fmt.Printf("- %s%s (joined: %s)\n", member.Name, isCurrent, member.CreatedAt)
+		if member != "" && !containsString(resolved, member) {
+			resolved = append(resolved, member)
+		}
+	}
+	return resolved, nil
+}
+
+// checkMembers returns ErrUnknownMember for the first name that isn't a
+// member, with the closest member name as a suggestion, and an error for
+// deactivated members
+func (s *TaskServiceImpl) checkMembers(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
 	}
 
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		var member *Member
+		for i, m := range members {
+			if m.Name == name {
+				member = &members[i]
+				break
+			}
+		}
+		if member == nil {
+			return unknownMember(name, members)
+		}
+		if !member.Active() {
+			return invalidInput("%s is deactivated", name)
+		}
+	}
 	return nil
 }
 
-func (s *TaskServiceImpl) HandleAddMember(name string) error {
+// findMember returns the member called name, or ErrUnknownMember with the
+// closest member name as a suggestion
+func (s *TaskServiceImpl) findMember(ctx context.Context, name string) (*Member, error) {
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range members {
+		if m.Name == name {
+			return &members[i], nil
+		}
+	}
+	return nil, unknownMember(name, members)
+}
+
+// FindOrphans returns the task owners and assignees that are not members,
+// with the closest member name as a suggestion
+func (s *TaskServiceImpl) FindOrphans(ctx context.Context) ([]OrphanedMember, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
+	}
+
+	orphans, err := s.repo.FindOrphanedMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(orphans) == 0 {
+		return orphans, nil
 	}
 
-	return s.repo.AddMember(name)
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orphans {
+		orphans[i].Suggestion = suggestMember(orphans[i].Member, members)
+	}
+	return orphans, nil
 }
 
-func (s *TaskServiceImpl) HandleSetCurrentMember(name string) error {
+// MyWork returns the current member's open tasks, split into overdue ones,
+// blocked ones, ones they own and ones they collaborate on, and the tasks they
+// finished this week
+func (s *TaskServiceImpl) MyWork(ctx context.Context) (*MyWork, error) {
 	if err := s.ensureConnect(); err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.repo.SetCurrentMember(name)
-}
+	member, err := s.repo.GetCurrentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tasks, err := s.repo.GetMemberTasks(ctx, member, startOfWeek(now))
+	if err != nil {
+		return nil, err
+	}
 
-// HandleAdd handles the add command
-func (s *TaskServiceImpl) HandleAdd(name string) {
-	task := NewTaskSchema{
-		Name: name,
+	work := &MyWork{
+		Member:            member,
+		Overdue:           []Task{},
+		Blocked:           []Task{},
+		Owned:             []Task{},
+		Collaborating:     []Task{},
+		CompletedThisWeek: []Task{},
+	}
+	for _, t := range tasks {
+		switch {
+		case t.Status == StatusDone:
+			work.CompletedThisWeek = append(work.CompletedThisWeek, t)
+		case t.Status == StatusBlocked:
+			work.Blocked = append(work.Blocked, t)
+		case t.Overdue(now):
+			work.Overdue = append(work.Overdue, t)
+		case t.Owner == member:
+			work.Owned = append(work.Owned, t)
+		default:
+			work.Collaborating = append(work.Collaborating, t)
+		}
 	}
+	return work, nil
+}
 
-	if !task.Validate() {
-		fmt.Println("Task name cannot be empty")
-		return
+// WorkloadReport returns the open work of every active member and of anyone
+// else still assigned to open tasks, busiest first
+func (s *TaskServiceImpl) WorkloadReport(ctx context.Context) ([]MemberWorkload, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.AddTask(Task{
-		Name: task.Name,
-	}); err != nil {
-		log.Println("Failed to add task: %v", err)
-		return
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := s.repo.GetStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.repo.QueryTasks(ctx, TaskQuery{})
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Task added successfully")
+	var names []string
+	for _, m := range members {
+		if m.Active() {
+			names = append(names, m.Name)
+		}
+	}
+	return buildWorkload(tasks, names, statuses, time.Now()), nil
 }
 
-func (s *TaskServiceImpl) HandleList(completed bool, all bool) {
-	tasks, err := s.repo.GetTask()
-	if err != nil {
-		log.Println("Could't get task: %v", err)
-		return
+// Stats returns the tasks created and completed per day or week since
+// opts.Since, their lead and cycle times and the number of open tasks at the
+// end of each period
+func (s *TaskServiceImpl) Stats(ctx context.Context, opts StatsOptions) (*Stats, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
 	}
 
-	if len(tasks) == 0 {
-		fmt.Println("No tasks found.")
-		return
+	now := time.Now()
+	if opts.Since == "" {
+		opts.Since = DefaultStatsSince
+	}
+	since, err := parseSince(opts.Since, now)
+	if err != nil {
+		return nil, invalidInput("%w", err)
+	}
+	if opts.Interval == "" {
+		opts.Interval = IntervalDay
+	}
+	if opts.Interval != IntervalDay && opts.Interval != IntervalWeek {
+		return nil, invalidInput("invalid interval %q: expected %s or %s", opts.Interval, IntervalDay, IntervalWeek)
 	}
 
-	fmt.Println("Tasks:")
-	for _, task := range tasks {
-		status := "[ ]" // Default: Not completed
-		if task.Status == "done" {
-			status = "[✔]"
+	member := strings.TrimSpace(opts.Member)
+	if member != "" {
+		members, err := s.resolveMembers(ctx, []string{member})
+		if err != nil {
+			return nil, err
 		}
-		fmt.Printf("%s [%d] %s \n", status, task.Id, task.Name)
+		if _, err := s.findMember(ctx, members[0]); err != nil {
+			return nil, err
+		}
+		member = members[0]
 	}
 
+	timelines, err := s.repo.GetTaskTimelines(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+	stats := buildStats(timelines, since, now, opts.Interval)
+	stats.Member = member
+	return stats, nil
 }
 
-func (s *TaskServiceImpl) HandleDone(id int) {
-	err := s.repo.DoneTask(id)
+// Standup returns what a member completed since the start of the last
+// working day and the tasks they have in progress and blocked, from the task
+// history. Tasks count when the member is assigned to them or made the
+// change. An empty member or "me" is the current member.
+func (s *TaskServiceImpl) Standup(ctx context.Context, member string) (*Standup, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
+	}
+
+	member = strings.TrimSpace(member)
+	if member == "" {
+		member = "me"
+	}
+	members, err := s.resolveMembers(ctx, []string{member})
 	if err != nil {
-		fmt.Printf("Error marking task %d as done: %v\n", id, err)
-		return
+		return nil, err
+	}
+	if _, err := s.findMember(ctx, members[0]); err != nil {
+		return nil, err
 	}
-	fmt.Printf("Task %d marked as done successfully.\n", id)
-}
 
-func (s *TaskServiceImpl) HandleDelete(id int) {
-	// Implementation of delete task
-	fmt.Printf("Deleting task with ID %d\n", id)
+	current, err := s.repo.GetTaskEvents(ctx, EventQuery{Member: members[0], CurrentStatus: true})
+	if err != nil {
+		return nil, err
+	}
+	return buildStandup(members[0], current, lastWorkingDay(time.Now())), nil
 }
 
-// HandleConnect handles the connect command
-func (s *TaskServiceImpl) HandleConnect(details ConnectionDetails) error {
-	// Connect to the external database
-	err := s.repo.ConnectToExternalDB(details)
-	if err != nil {
-		return fmt.Errorf("error connecting to database: %v", err)
+// Digest returns the team's activity since a number of days or weeks back
+// such as 7d, the default, or a YYYY-MM-DD date, from the task history
+func (s *TaskServiceImpl) Digest(ctx context.Context, since string) (*Digest, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("Successfully connected to external database!")
+	now := time.Now()
+	if since == "" {
+		since = DefaultDigestSince
+	}
+	start, err := parseSince(since, now)
+	if err != nil {
+		return nil, invalidInput("%w", err)
+	}
 
-	// After connecting, check if we need to set up a user
-	err = s.HandleSetupMember()
+	events, err := s.repo.GetTaskEvents(ctx, EventQuery{Since: start})
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.repo.GetTaskEvents(ctx, EventQuery{CurrentStatus: true})
 	if err != nil {
-		return fmt.Errorf("error setting up member: %v", err)
+		return nil, err
+	}
+	return buildDigest(events, current, start, now), nil
+}
+
+// startOfWeek returns midnight on the Monday of the week of t
+func startOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+}
+
+// ListStatuses returns the names of the task statuses in workflow order
+func (s *TaskServiceImpl) ListStatuses(ctx context.Context) ([]string, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetStatuses(ctx)
+}
+
+// Connect switches the repository to an external database
+func (s *TaskServiceImpl) Connect(ctx context.Context, details ConnectionDetails) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
 	}
 
-	// Get current member to confirm
-	member, err := s.repo.GetCurrentMember()
-	if err == nil {
-		fmt.Printf("Connected as: %s\n", member)
+	if err := s.repo.ConnectToExternalDB(ctx, details); err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
 	}
 
 	return nil
 }
 
-func (s *TaskServiceImpl) HandleSettings() {
-	// Implementation for HandleSettings
-	fmt.Println("Settings functionality not implemented yet")
+// ListMembers returns all members ordered by name
+func (s *TaskServiceImpl) ListMembers(ctx context.Context) ([]Member, error) {
+	if err := s.ensureConnect(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetAllMembers(ctx)
 }
 
-func (s *TaskServiceImpl) HandlePriority() {
-	// Implementation for HandlePriority
-	fmt.Println("Priority functionality not implemented yet")
+// CurrentMember returns the name of the current member. Returns
+// ErrNoCurrentMember when none is set.
+func (s *TaskServiceImpl) CurrentMember(ctx context.Context) (string, error) {
+	if err := s.ensureConnect(); err != nil {
+		return "", err
+	}
+
+	return s.repo.GetCurrentMember(ctx)
 }
 
-// HandleUpdate handles the update command
-func (s *TaskServiceImpl) HandleUpdate(data UpdateTaskSchema) {
-	// Get all tasks
-	tasks, err := s.repo.GetTask()
+// AddMember adds a member if they don't exist yet. Only admins may add
+// members, except for the first member of a database, who becomes its admin.
+func (s *TaskServiceImpl) AddMember(ctx context.Context, name string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.canAddMember(ctx, name); err != nil {
+		return err
+	}
+	members, err := s.repo.GetAllMembers(ctx)
 	if err != nil {
-		fmt.Println("Error retrieving tasks:", err)
-		return
+		return err
 	}
 
-	// Check if the task ID exists
-	var found bool
-	for _, task := range tasks {
-		if task.Id == data.ID {
-			found = true
-			break
+	if err := s.repo.AddMember(ctx, name); err != nil {
+		return err
+	}
+	// Whoever adds the first member of a database is that member, its admin
+	if len(members) == 0 {
+		if _, err := s.repo.GetCurrentMember(ctx); errors.Is(err, ErrNoCurrentMember) {
+			return s.repo.SetCurrentMember(ctx, name)
 		}
 	}
+	return nil
+}
 
-	if !found {
-		fmt.Printf("No task found with ID %d\n", data.ID)
-		return
+// SetMemberRole changes a member's role. Only admins may change roles, and
+// the last admin can't be demoted.
+func (s *TaskServiceImpl) SetMemberRole(ctx context.Context, name string, role string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
 	}
 
-	// Determine the status based on the Completed field
-	status := "pending"
-	if data.Completed != nil && *data.Completed {
-		status = "done"
-	} else if data.Status != "" {
-		status = data.Status
+	role, err := ParseMemberRole(role)
+	if err != nil {
+		return invalidInput("%w", err)
+	}
+	if err := s.canManageMembers(ctx); err != nil {
+		return err
+	}
+
+	member, err := s.findMember(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role != MemberRoleAdmin {
+		if err := s.checkLastAdmin(ctx, member); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.SetMemberRole(ctx, name, role)
+}
+
+// RenameMember renames a member everywhere they are referred to. Only
+// admins may rename members.
+func (s *TaskServiceImpl) RenameMember(ctx context.Context, name string, newName string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return invalidInput("name cannot be empty")
+	}
+	if newName == "me" {
+		return invalidInput("me always means the current user and can't be a member name")
+	}
+	if err := s.canManageMembers(ctx); err != nil {
+		return err
+	}
+	if _, err := s.findMember(ctx, name); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetMember(ctx, newName); err == nil {
+		return invalidInput("%s is already a member", newName)
+	} else if !errors.Is(err, ErrUnknownMember) {
+		return err
+	}
+
+	return s.repo.RenameMember(ctx, name, newName)
+}
+
+// DeactivateMember deactivates a member who left. Their open tasks are
+// reassigned to reassignTo, which is required when they have any; done tasks
+// and comments keep their name. Returns the number of tasks reassigned. Only
+// admins may deactivate members, and the last admin can't be deactivated.
+func (s *TaskServiceImpl) DeactivateMember(ctx context.Context, name string, reassignTo string) (int, error) {
+	if err := s.ensureConnect(); err != nil {
+		return 0, err
+	}
+
+	if err := s.canManageMembers(ctx); err != nil {
+		return 0, err
+	}
+	member, err := s.findMember(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if !member.Active() {
+		return 0, invalidInput("%s is already deactivated", name)
+	}
+	if err := s.checkLastAdmin(ctx, member); err != nil {
+		return 0, err
 	}
 
-	// Update task using repository method
-	err = s.repo.UpdateTask(data.ID, data.Name, status, data.Collaborator)
+	usage, err := s.repo.GetMemberUsage(ctx, name)
 	if err != nil {
-		fmt.Printf("Error updating task: %v\n", err)
-		return
+		return 0, err
+	}
+	reassignTo, err = s.checkReassign(ctx, name, reassignTo)
+	if err != nil {
+		return 0, err
+	}
+	if reassignTo == "" && usage.OpenTasks > 0 {
+		return 0, invalidInput("%s still has open tasks; choose who takes them over with -reassign-to", name)
 	}
 
-	fmt.Println("Task updated successfully")
+	return s.repo.DeactivateMember(ctx, name, reassignTo)
 }
 
-func (s *TaskServiceImpl) HandleViewTask(id int, format string) {
-	// Get all tasks
-	tasks, err := s.repo.GetTask()
+// ActivateMember reactivates a deactivated member. Only admins may activate
+// members.
+func (s *TaskServiceImpl) ActivateMember(ctx context.Context, name string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
+
+	if err := s.canManageMembers(ctx); err != nil {
+		return err
+	}
+	member, err := s.findMember(ctx, name)
 	if err != nil {
-		fmt.Println("Error retrieving tasks:", err)
-		return
+		return err
+	}
+	if member.Active() {
+		return invalidInput("%s is already active", name)
+	}
+
+	return s.repo.ActivateMember(ctx, name)
+}
+
+// RemoveMember deletes a member that was added by mistake. Members who
+// changed tasks or wrote comments are part of the history and can only be
+// deactivated. Their tasks are reassigned to reassignTo, which is required
+// when they own any. Returns the number of tasks reassigned. Only admins may
+// remove members, and the last admin can't be removed.
+func (s *TaskServiceImpl) RemoveMember(ctx context.Context, name string, reassignTo string) (int, error) {
+	if err := s.ensureConnect(); err != nil {
+		return 0, err
+	}
+
+	if err := s.canManageMembers(ctx); err != nil {
+		return 0, err
+	}
+	member, err := s.findMember(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.checkLastAdmin(ctx, member); err != nil {
+		return 0, err
 	}
 
-	// Find the task with the matching ID
-	var task *Task
-	for i, t := range tasks {
-		if t.Id == id {
-			task = &tasks[i]
-			break
+	usage, err := s.repo.GetMemberUsage(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if usage.Changes > 0 {
+		return 0, invalidInput("%s has changed tasks or written comments; deactivate them instead to keep the history", name)
+	}
+	reassignTo, err = s.checkReassign(ctx, name, reassignTo)
+	if err != nil {
+		return 0, err
+	}
+	if reassignTo == "" {
+		var filter TaskFilter
+		filter.Add("owner", OpEqual, name)
+		owned, err := s.repo.QueryTasks(ctx, TaskQuery{Filter: filter})
+		if err != nil {
+			return 0, err
+		}
+		if len(owned) > 0 {
+			return 0, invalidInput("%s still owns tasks; choose who takes them over with -reassign-to", name)
 		}
 	}
 
-	if task == nil {
-		fmt.Printf("Task with ID %d not found.\n", id)
-		return
+	return s.repo.RemoveMember(ctx, name, reassignTo)
+}
+
+// checkReassign checks the member that takes over tasks from name and
+// resolves "me". An empty reassignTo is returned as it is.
+func (s *TaskServiceImpl) checkReassign(ctx context.Context, name string, reassignTo string) (string, error) {
+	resolved, err := s.resolveMembers(ctx, []string{reassignTo})
+	if err != nil || len(resolved) == 0 {
+		return "", err
 	}
+	reassignTo = resolved[0]
 
-	if format == "html" {
-		// Convert to view.Task
-		viewTask := Task{
-			Id:        task.Id,
-			Name:      task.Name,
-			Status:    task.Status,
-			CreatedAt: task.CreatedAt,
+	if reassignTo == name {
+		return "", invalidInput("can't reassign the tasks of %s to themselves", name)
+	}
+	if err := s.checkMembers(ctx, resolved); err != nil {
+		return "", err
+	}
+	member, err := s.repo.GetMember(ctx, reassignTo)
+	if err != nil {
+		return "", err
+	}
+	if member.Role == MemberRoleViewer {
+		return "", invalidInput("%s is a viewer and can't take over tasks", reassignTo)
+	}
+	return reassignTo, nil
+}
+
+// checkLastAdmin returns an error when member is the only active admin
+func (s *TaskServiceImpl) checkLastAdmin(ctx context.Context, member *Member) error {
+	if member.Role != MemberRoleAdmin {
+		return nil
+	}
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		if m.Name != member.Name && m.Role == MemberRoleAdmin && m.Active() {
+			return nil
 		}
+	}
+	return invalidInput("%s is the only admin; make another member admin first", member.Name)
+}
+
+// SetCurrentMember switches to another member. Switching to a name that
+// isn't a member yet adds it, which needs the same permission as AddMember.
+// Switching to an existing member is checked by canSwitchTo.
+func (s *TaskServiceImpl) SetCurrentMember(ctx context.Context, name string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
 
-		if err := GenerateAndDisplayHTML(viewTask); err != nil {
-			fmt.Printf("Error displaying HTML view: %v\n", err)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return invalidInput("name cannot be empty")
+	}
+	if err := s.canAddMember(ctx, name); err != nil {
+		return err
+	}
+	member, err := s.repo.GetMember(ctx, name)
+	if err != nil && !errors.Is(err, ErrUnknownMember) {
+		return err
+	}
+	if member != nil {
+		if !member.Active() {
+			return invalidInput("%s is deactivated", name)
 		}
-	} else {
-		// Display in text format
-		status := "Pending"
-		if task.Status == "done" {
-			status = "Completed"
+		if err := s.canSwitchTo(ctx, member); err != nil {
+			return err
 		}
-		fmt.Printf("Task ID: %d\n", task.Id)
-		fmt.Printf("Description: %s\n", task.Name)
-		fmt.Printf("Status: %s\n", status)
-		fmt.Printf("Created At: %s\n", task.CreatedAt)
 	}
+	hash, err := s.repo.GetPasswordHash(ctx, name)
+	if err != nil && !errors.Is(err, ErrUnknownMember) {
+		return err
+	}
+	if hash != "" {
+		return permissionDenied("%s has a password; log in instead", name)
+	}
+
+	return s.repo.SetCurrentMember(ctx, name)
 }
 
-func (s *TaskServiceImpl) HandleViewAllTasks(format string) {
-	// Get all tasks
-	fmt.Println("DEBUG: HandleViewAllTasks called with format:", format)
-	tasks, err := s.repo.GetTask()
+// SetPassword sets a member's password, or removes it when password is
+// empty. Members with a password must log in before they can act. Members
+// can change their own password and admins can change anyone's.
+func (s *TaskServiceImpl) SetPassword(ctx context.Context, name string, password string) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
+
+	if err := s.checkMembers(ctx, []string{name}); err != nil {
+		return err
+	}
+	current, err := s.currentMember(ctx)
+	if err != nil && !errors.Is(err, ErrNoCurrentMember) {
+		return err
+	}
+	if current == nil || current.Name != name {
+		if err := s.canManageMembers(ctx); err != nil {
+			return err
+		}
+	}
+
+	if password == "" {
+		return s.repo.SetPasswordHash(ctx, name, "")
+	}
+	if len(password) < minPasswordLength {
+		return invalidInput("password must have at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return invalidInput("password can't be longer than 72 bytes")
+	}
 	if err != nil {
-		fmt.Println("Error retrieving tasks:", err)
-		return
+		return fmt.Errorf("failed to hash password: %v", err)
 	}
+	return s.repo.SetPasswordHash(ctx, name, string(hash))
+}
 
-	if len(tasks) == 0 {
-		fmt.Println("No tasks found.")
-		return
+// Login checks a member's password and makes them the current member with
+// a session that lasts ttl, or DefaultSessionTTL when ttl is 0. Returns when
+// the session expires.
+func (s *TaskServiceImpl) Login(ctx context.Context, name string, password string, ttl time.Duration) (time.Time, error) {
+	if err := s.ensureConnect(); err != nil {
+		return time.Time{}, err
 	}
 
-	fmt.Println("DEBUG: Found", len(tasks), "tasks")
+	if ttl == 0 {
+		ttl = DefaultSessionTTL
+	}
+	if ttl < 0 || ttl > MaxSessionTTL {
+		return time.Time{}, invalidInput("session length must be between 0 and %v", MaxSessionTTL)
+	}
 
-	if format == "html" {
-		fmt.Println("DEBUG: Generating HTML view")
-		// Convert tasks to view.Task
-		var viewTasks []Task
-		for _, task := range tasks {
-			viewTask := Task{
-				Id:        task.Id,
-				Name:      task.Name,
-				Status:    task.Status,
-				CreatedAt: task.CreatedAt,
-			}
-			viewTasks = append(viewTasks, viewTask)
-		}
-
-		fmt.Println("DEBUG: Calling GenerateAndDisplayTaskList with", len(viewTasks), "tasks")
-		if err := GenerateAndDisplayTaskList(viewTasks); err != nil {
-			fmt.Printf("Error displaying HTML view: %v\n", err)
-		} else {
-			fmt.Println("DEBUG: GenerateAndDisplayTaskList completed successfully")
-		}
-	} else {
-		// Display in text format
-		fmt.Println("Tasks:")
-		for _, task := range tasks {
-			status := "[ ]" // Default: Not completed
-			if task.Status == "done" {
-				status = "[✔]"
-			}
-			fmt.Printf("%s [%d] %s\n", status, task.Id, task.Name)
+	name = strings.TrimSpace(name)
+	if err := s.checkMembers(ctx, []string{name}); err != nil {
+		return time.Time{}, err
+	}
+	hash, err := s.repo.GetPasswordHash(ctx, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if hash == "" {
+		return time.Time{}, invalidInput("%s has no password to log in with", name)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return time.Time{}, permissionDenied("wrong password for %s", name)
+	}
+
+	return s.repo.StartSession(ctx, name, ttl)
+}
+
+// Logout ends the local user's login session
+func (s *TaskServiceImpl) Logout(ctx context.Context) error {
+	if err := s.ensureConnect(); err != nil {
+		return err
+	}
+
+	return s.repo.EndSession(ctx)
+}
+
+// currentMember returns the current member with their role. Deactivated
+// members may not act.
+func (s *TaskServiceImpl) currentMember(ctx context.Context) (*Member, error) {
+	name, err := s.repo.GetCurrentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	member, err := s.repo.GetMember(ctx, name)
+	if errors.Is(err, ErrUnknownMember) {
+		return nil, fmt.Errorf("%w; if they were renamed, use switch-user with the new name", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !member.Active() {
+		return nil, permissionDenied("%s is deactivated", name)
+	}
+	return member, nil
+}
+
+// canEdit returns the current member, or ErrPermissionDenied when they are a
+// viewer and may not change anything
+func (s *TaskServiceImpl) canEdit(ctx context.Context) (*Member, error) {
+	member, err := s.currentMember(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == MemberRoleViewer {
+		return nil, permissionDenied("%s is a viewer and can't change tasks", member.Name)
+	}
+	return member, nil
+}
+
+// canManageMembers returns ErrPermissionDenied unless the current member is
+// an admin. Anyone may add the first member of an empty database.
+func (s *TaskServiceImpl) canManageMembers(ctx context.Context) error {
+	members, err := s.repo.GetAllMembers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	member, err := s.currentMember(ctx)
+	if errors.Is(err, ErrNoCurrentMember) {
+		return permissionDenied("only admins can add members; ask an admin to add you")
+	}
+	if err != nil {
+		return err
+	}
+	if member.Role != MemberRoleAdmin {
+		return permissionDenied("only admins can manage members")
+	}
+	return nil
+}
+
+// canSwitchTo returns ErrPermissionDenied unless the local user may become
+// target without their password. Admins may switch to anyone and other
+// members only to themselves. A user without a current member yet, e.g. on a
+// new machine, may pick any member but an admin.
+func (s *TaskServiceImpl) canSwitchTo(ctx context.Context, target *Member) error {
+	current, err := s.currentMember(ctx)
+	switch {
+	case err == nil:
+		if current.Name == target.Name || current.Role == MemberRoleAdmin {
+			return nil
+		}
+		return permissionDenied("only admins can switch to another member")
+	case errors.Is(err, ErrNoCurrentMember), errors.Is(err, ErrUnknownMember):
+		if target.Role != MemberRoleAdmin {
+			return nil
 		}
+		return permissionDenied("%s is an admin; log in with their password instead", target.Name)
+	}
+	return err
+}
+
+// canAddMember checks canManageMembers when name isn't a member yet
+func (s *TaskServiceImpl) canAddMember(ctx context.Context, name string) error {
+	_, err := s.repo.GetMember(ctx, name)
+	if errors.Is(err, ErrUnknownMember) {
+		return s.canManageMembers(ctx)
 	}
+	return err
 }
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ryuux05/task-cli/lineedit"
+)
+
+// prompt reads a value on the bottom line of the screen
+type prompt struct {
+	label string
+	value []rune
+	// completions are offered by Tab
+	completions []string
+	// confirm prompts take a single y or n key instead of a line
+	confirm bool
+	// submit is called with the value on Enter. An error keeps the prompt
+	// open so the value can be corrected.
+	submit func(value string) error
+}
+
+func (m *model) handlePromptKey(key rune) {
+	p := m.prompt
+	m.message = ""
+
+	if p.confirm {
+		m.prompt = nil
+		if key == 'y' || key == 'Y' {
+			if err := p.submit(""); err != nil {
+				m.setError(err)
+			}
+		}
+		return
+	}
+
+	switch key {
+	case lineedit.KeyEnter, '\n':
+		if err := p.submit(string(p.value)); err != nil {
+			m.setError(err)
+			return
+		}
+		m.prompt = nil
+	case lineedit.KeyEscape, lineedit.KeyCtrlC, lineedit.KeyCtrlG:
+		m.prompt = nil
+	case lineedit.KeyBackspace, lineedit.KeyCtrlH:
+		if len(p.value) > 0 {
+			p.value = p.value[:len(p.value)-1]
+		}
+	case lineedit.KeyCtrlU:
+		p.value = p.value[:0]
+	case lineedit.KeyCtrlW:
+		end := len(p.value)
+		for end > 0 && unicode.IsSpace(p.value[end-1]) {
+			end--
+		}
+		for end > 0 && !unicode.IsSpace(p.value[end-1]) {
+			end--
+		}
+		p.value = p.value[:end]
+	case lineedit.KeyTab:
+		p.complete()
+	default:
+		if key < unicode.MaxRune && unicode.IsPrint(key) {
+			p.value = append(p.value, key)
+		}
+	}
+}
+
+// complete extends the value to the longest prefix shared by the
+// completions it matches
+func (p *prompt) complete() {
+	value := string(p.value)
+	var match string
+	found := false
+	for _, c := range p.completions {
+		if !strings.HasPrefix(c, value) {
+			continue
+		}
+		if !found {
+			match, found = c, true
+			continue
+		}
+		for !strings.HasPrefix(c, match) {
+			match = match[:len(match)-1]
+		}
+	}
+	if found {
+		p.value = []rune(match)
+	}
+}
@@ -0,0 +1,374 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ryuux05/task-cli/task"
+)
+
+// Terminal control sequences
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+	hideCursor     = "\x1b[?25l"
+	showCursor     = "\x1b[?25h"
+
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleRed     = "\x1b[31m"
+)
+
+// minSplitWidth is the narrowest terminal that shows the detail pane next to
+// the list
+const minSplitWidth = 90
+
+const keyHelp = "a add  d done  e edit  D delete  c assign  u unassign  m member  / filter  tab board  q quit"
+
+// draw redraws the whole screen
+func (m *model) draw() {
+	width, height := m.width, m.height
+	if height < 4 || width < 20 {
+		fmt.Fprint(m.out, "\x1b[H\x1b[2J"+fit("Terminal too small", width))
+		return
+	}
+
+	lines := []string{m.header(width)}
+	bodyHeight := height - 3
+	switch {
+	case m.view == viewBoard:
+		lines = append(lines, m.board(width, bodyHeight)...)
+	case width >= minSplitWidth:
+		listWidth := width * 55 / 100
+		list := m.list(listWidth, bodyHeight)
+		detail := m.detailPane(width-listWidth-3, bodyHeight)
+		for i := 0; i < bodyHeight; i++ {
+			lines = append(lines, list[i]+styleDim+" │ "+styleReset+detail[i])
+		}
+	case m.showDetail:
+		lines = append(lines, m.detailPane(width, bodyHeight)...)
+	default:
+		lines = append(lines, m.list(width, bodyHeight)...)
+	}
+	lines = append(lines, styleDim+fit(keyHelp, width)+styleReset)
+
+	// The bottom line holds the prompt or the last message. It is kept one
+	// column short so the terminal doesn't scroll.
+	var cursor int
+	switch {
+	case m.prompt != nil:
+		line := m.prompt.label + string(m.prompt.value)
+		// Keep the end of a long value in view
+		if n := utf8.RuneCountInString(line); n > width-2 {
+			line = string([]rune(line)[n-(width-2):])
+		}
+		cursor = utf8.RuneCountInString(line) + 1
+		lines = append(lines, fit(line, width-1))
+	case m.isError:
+		lines = append(lines, styleRed+fit(clean(m.message), width-1)+styleReset)
+	default:
+		lines = append(lines, fit(clean(m.message), width-1))
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line + styleReset + "\x1b[K")
+	}
+	if m.prompt != nil {
+		fmt.Fprintf(&b, "\x1b[%d;%dH%s", height, cursor, showCursor)
+	} else {
+		b.WriteString(hideCursor)
+	}
+	fmt.Fprint(m.out, b.String())
+}
+
+// header shows the view, the current member and the filter
+func (m *model) header(width int) string {
+	tabs := []string{" List ", " Board "}
+	var b strings.Builder
+	used := 0
+	for i, tab := range tabs {
+		if view(i) == m.view {
+			b.WriteString(styleReverse + tab + styleReset)
+		} else {
+			b.WriteString(tab)
+		}
+		used += len(tab)
+	}
+
+	info := fmt.Sprintf("  %d tasks", len(m.tasks))
+	if m.current != "" {
+		info += "  member: " + m.current
+	}
+	if m.filter != "" {
+		info += "  filter: " + m.filter
+	}
+	b.WriteString(styleBold + fit(clean(info), width-used) + styleReset)
+	return b.String()
+}
+
+// list renders the task list, scrolled to keep the selection visible
+func (m *model) list(width, height int) []string {
+	lines := make([]string, 0, height)
+	lines = append(lines, styleBold+fit(fmt.Sprintf("%-4s %-3s %-6s %s", "ID", "", "PRI", "NAME"), width)+styleReset)
+	rows := height - 1
+
+	selected := m.selectedIndex()
+	if selected >= 0 {
+		if selected < m.scroll {
+			m.scroll = selected
+		}
+		if selected >= m.scroll+rows {
+			m.scroll = selected - rows + 1
+		}
+	}
+	m.scroll = clamp(m.scroll, 0, max(len(m.tasks)-rows, 0))
+
+	if len(m.tasks) == 0 {
+		empty := "No tasks. Press a to add one."
+		if m.filter != "" {
+			empty = "No tasks match the filter. Press / to change it."
+		}
+		lines = append(lines, styleDim+fit(empty, width)+styleReset)
+	}
+
+	for i := m.scroll; i < len(m.tasks) && len(lines) < height; i++ {
+		t := m.tasks[i]
+		row := fmt.Sprintf("%-4d %s %-6s %s", t.Id, checkbox(t.Status), task.PriorityName(t.Priority), clean(t.Name))
+		if names := t.Members(task.RoleAssignee, task.RoleReviewer, task.RoleWatcher); len(names) > 0 {
+			row += " (" + clean(strings.Join(names, ", ")) + ")"
+		}
+		row = fit(row, width)
+
+		switch {
+		case i == selected:
+			row = styleReverse + row + styleReset
+		case t.Status == "done":
+			row = styleDim + row + styleReset
+		}
+		lines = append(lines, row)
+	}
+	return padLines(lines, width, height)
+}
+
+// detailPane renders the selected task with its comments and history
+func (m *model) detailPane(width, height int) []string {
+	d := m.detail
+	if d == nil {
+		return padLines([]string{styleDim + fit("No task selected", width) + styleReset}, width, height)
+	}
+
+	var lines []string
+	add := func(s string) {
+		lines = append(lines, fit(s, width))
+	}
+	heading := func(s string) {
+		lines = append(lines, "", styleBold+fit(s, width)+styleReset)
+	}
+
+	lines = append(lines, styleBold+fit(fmt.Sprintf("#%d %s", d.Id, clean(d.Name)), width)+styleReset)
+	add("Status:       " + d.Status)
+	add("Priority:     " + task.PriorityName(d.Priority))
+	add("Owner:        " + clean(d.Owner))
+	var assignees []string
+	for _, a := range d.Assignees {
+		switch a.Role {
+		case task.RoleOwner:
+		case task.RoleAssignee:
+			assignees = append(assignees, a.Member)
+		default:
+			assignees = append(assignees, a.String())
+		}
+	}
+	if len(assignees) == 0 {
+		assignees = []string{"-"}
+	}
+	add("Assignees:    " + clean(strings.Join(assignees, ", ")))
+	add("Created:      " + d.CreatedAt)
+
+	if d.Description != "" {
+		heading("Description")
+		for _, line := range wrap(d.Description, width-2) {
+			add("  " + line)
+		}
+	}
+
+	heading(fmt.Sprintf("Comments (%d)", len(d.Comments)))
+	if len(d.Comments) == 0 {
+		add("  none")
+	}
+	for _, c := range d.Comments {
+		lines = append(lines, styleDim+fit(fmt.Sprintf("  %s, %s", clean(c.Author), c.CreatedAt), width)+styleReset)
+		for _, line := range wrap(c.Body, width-4) {
+			add("    " + line)
+		}
+	}
+
+	heading("History")
+	for _, event := range history(d) {
+		add("  " + event)
+	}
+
+	return padLines(lines, width, height)
+}
+
+// history lists the task's recorded events and its comments, oldest first
+func history(d *task.TaskDetail) []string {
+	type event struct {
+		at, text string
+	}
+	var events []event
+	for _, e := range d.History {
+		text := e.Type
+		switch e.Type {
+		case task.EventCreated:
+			text = "created"
+		case task.EventStatus:
+			text = "set to " + e.Value
+		}
+		if e.Actor != "" {
+			text += " by " + clean(e.Actor)
+		}
+		events = append(events, event{e.CreatedAt, text})
+	}
+	for _, c := range d.Comments {
+		events = append(events, event{c.CreatedAt, "commented on by " + clean(c.Author)})
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].at < events[j].at })
+
+	lines := make([]string, len(events))
+	for i, e := range events {
+		lines[i] = e.at + "  " + e.text
+	}
+	return lines
+}
+
+// board renders one column per status with the tasks in it
+func (m *model) board(width, height int) []string {
+	statuses, columns := m.columns()
+	if len(statuses) == 0 {
+		return padLines(nil, width, height)
+	}
+
+	columnWidth := (width - (len(statuses) - 1)) / len(statuses)
+	selectedColumn, selectedRow := m.boardPosition(columns)
+	rows := height - 1
+
+	cells := make([][]string, len(statuses))
+	for c, status := range statuses {
+		cells[c] = append(cells[c], styleBold+fit(fmt.Sprintf("%s (%d)", status, len(columns[c])), columnWidth)+styleReset)
+
+		start := 0
+		if c == selectedColumn && selectedRow >= rows {
+			start = selectedRow - rows + 1
+		}
+		for r := start; r < len(columns[c]) && len(cells[c]) < height; r++ {
+			t := columns[c][r]
+			card := fit(fmt.Sprintf("#%d %s", t.Id, clean(t.Name)), columnWidth)
+			if t.Id == m.selected {
+				card = styleReverse + card + styleReset
+			}
+			cells[c] = append(cells[c], card)
+		}
+		cells[c] = padLines(cells[c], columnWidth, height)
+	}
+
+	lines := make([]string, height)
+	for i := range lines {
+		parts := make([]string, len(cells))
+		for c := range cells {
+			parts[c] = cells[c][i]
+		}
+		lines[i] = strings.Join(parts, styleDim+"│"+styleReset)
+	}
+	return lines
+}
+
+func checkbox(status string) string {
+	switch status {
+	case task.StatusDone:
+		return "[x]"
+	case task.StatusInProgress:
+		return "[~]"
+	case task.StatusBlocked:
+		return "[!]"
+	}
+	return "[ ]"
+}
+
+// fit truncates or pads s to exactly width columns
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		return string([]rune(s)[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+// padLines adds blank lines so there are height lines, and drops the rest
+func padLines(lines []string, width, height int) []string {
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", max(width, 0)))
+	}
+	return lines[:height]
+}
+
+// clean replaces line breaks and tabs with spaces and drops other control
+// characters, so a value stays on its line
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || r == '\r' {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// wrap splits text into lines of at most width runes, breaking at spaces
+// where possible
+func wrap(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(clean(paragraph)) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
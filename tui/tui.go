@@ -0,0 +1,507 @@
+// Package tui is a full-screen terminal UI for tasks: a filterable list with
+// a detail pane, and a kanban board with one column per status. It works on
+// top of task.TaskService and polls it so changes made by other processes
+// show up.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/ryuux05/task-cli/lineedit"
+	"github.com/ryuux05/task-cli/task"
+)
+
+// refreshInterval is how often the tasks are reloaded to pick up changes made
+// by other processes
+const refreshInterval = 2 * time.Second
+
+// resizeInterval is how often the terminal size is checked
+const resizeInterval = 250 * time.Millisecond
+
+type view int
+
+const (
+	viewList view = iota
+	viewBoard
+)
+
+// model is the state of the UI
+type model struct {
+	ctx     context.Context
+	service task.TaskService
+	out     io.Writer
+	fd      int
+
+	view   view
+	filter string
+	// showDetail shows the detail pane on its own when the terminal is too
+	// narrow to show it next to the list
+	showDetail bool
+
+	tasks    []task.Task
+	statuses []string
+	members  []task.Member
+	current  string
+	detail   *task.TaskDetail
+
+	// selected is the ID of the highlighted task, kept across reloads
+	selected int
+	// scroll is the first visible row of the list
+	scroll int
+
+	message string
+	isError bool
+	prompt  *prompt
+
+	width, height int
+}
+
+// Run shows the UI until the user quits or ctx is cancelled
+func Run(ctx context.Context, service task.TaskService) error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		return errors.New("the terminal UI needs an interactive terminal")
+	}
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %v", err)
+	}
+	defer term.Restore(in, state)
+
+	fmt.Fprint(os.Stdout, enterAltScreen+hideCursor)
+	defer fmt.Fprint(os.Stdout, showCursor+exitAltScreen)
+
+	m := &model{ctx: ctx, service: service, out: os.Stdout, fd: out}
+	m.resized()
+	m.reload()
+
+	keys := make(chan rune)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go readKeys(os.Stdin, keys, readErr, done)
+
+	refresh := time.NewTicker(refreshInterval)
+	defer refresh.Stop()
+	resize := time.NewTicker(resizeInterval)
+	defer resize.Stop()
+
+	m.draw()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read input: %v", err)
+		case key := <-keys:
+			if m.handleKey(key) {
+				return nil
+			}
+		case <-refresh.C:
+			// Don't move things around while the user is typing
+			if m.prompt == nil {
+				m.reload()
+			}
+		case <-resize.C:
+			if !m.resized() {
+				continue
+			}
+		}
+		m.draw()
+	}
+}
+
+// readKeys sends key presses on keys until reading fails or done is closed.
+// A read in progress when done is closed ends with the next key press, which
+// is dropped, so the goroutine doesn't outlive the UI by more than one key.
+func readKeys(in io.Reader, keys chan<- rune, errs chan<- error, done <-chan struct{}) {
+	r := bufio.NewReader(in)
+	for {
+		key, err := lineedit.ReadKey(r)
+		if err != nil {
+			select {
+			case errs <- err:
+			case <-done:
+			}
+			return
+		}
+		select {
+		case keys <- key:
+		case <-done:
+			return
+		}
+	}
+}
+
+// resized updates the terminal size and reports whether it changed
+func (m *model) resized() bool {
+	width, height, err := term.GetSize(m.fd)
+	if err != nil {
+		width, height = 80, 24
+	}
+	if width == m.width && height == m.height {
+		return false
+	}
+	m.width, m.height = width, height
+	return true
+}
+
+// reload fetches the tasks, statuses and members and keeps the selection on
+// the same task, or the one now in its place if it is gone
+func (m *model) reload() {
+	tasks, err := m.service.ListTasks(m.ctx, task.ListOptions{All: true, Filter: m.filter})
+	if err != nil {
+		m.setError(err)
+		return
+	}
+
+	previous := m.selectedIndex()
+	m.tasks = tasks
+	if m.selectedIndex() < 0 {
+		m.selected = 0
+		if len(tasks) > 0 {
+			m.selected = tasks[clamp(previous, 0, len(tasks)-1)].Id
+		}
+	}
+
+	if statuses, err := m.service.ListStatuses(m.ctx); err == nil {
+		m.statuses = statuses
+	}
+	if members, err := m.service.ListMembers(m.ctx); err == nil {
+		m.members = members
+	}
+	current, err := m.service.CurrentMember(m.ctx)
+	if err != nil && !errors.Is(err, task.ErrNoCurrentMember) {
+		m.setError(err)
+	}
+	m.current = current
+
+	m.loadDetail()
+}
+
+// loadDetail fetches the comments of the selected task
+func (m *model) loadDetail() {
+	m.detail = nil
+	if m.selected == 0 {
+		return
+	}
+	detail, err := m.service.GetTask(m.ctx, m.selected)
+	if err != nil {
+		return
+	}
+	m.detail = detail
+}
+
+func (m *model) setMessage(format string, args ...interface{}) {
+	m.message = fmt.Sprintf(format, args...)
+	m.isError = false
+}
+
+func (m *model) setError(err error) {
+	m.message = err.Error()
+	m.isError = true
+}
+
+// selectedIndex returns the index of the selected task, or -1
+func (m *model) selectedIndex() int {
+	for i, t := range m.tasks {
+		if t.Id == m.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *model) selectedTask() *task.Task {
+	if i := m.selectedIndex(); i >= 0 {
+		return &m.tasks[i]
+	}
+	return nil
+}
+
+// selectIndex selects the task at index i of the list, clamped to the list
+func (m *model) selectIndex(i int) {
+	if len(m.tasks) == 0 {
+		return
+	}
+	m.selected = m.tasks[clamp(i, 0, len(m.tasks)-1)].Id
+	m.loadDetail()
+}
+
+// columns returns the board columns: one per status, in workflow order, with
+// the tasks in each
+func (m *model) columns() ([]string, [][]task.Task) {
+	statuses := append([]string(nil), m.statuses...)
+	for _, t := range m.tasks {
+		if !containsString(statuses, t.Status) {
+			statuses = append(statuses, t.Status)
+		}
+	}
+
+	columns := make([][]task.Task, len(statuses))
+	for _, t := range m.tasks {
+		for i, status := range statuses {
+			if t.Status == status {
+				columns[i] = append(columns[i], t)
+			}
+		}
+	}
+	return statuses, columns
+}
+
+// boardPosition returns the column and row of the selected task on the board
+func (m *model) boardPosition(columns [][]task.Task) (int, int) {
+	for c, column := range columns {
+		for r, t := range column {
+			if t.Id == m.selected {
+				return c, r
+			}
+		}
+	}
+	return 0, 0
+}
+
+// moveOnBoard moves the selection by dc columns and dr rows. Moving to
+// another column skips empty columns and keeps the row where possible.
+func (m *model) moveOnBoard(dc, dr int) {
+	_, columns := m.columns()
+	c, r := m.boardPosition(columns)
+
+	if dc != 0 {
+		next := c + dc
+		for next >= 0 && next < len(columns) && len(columns[next]) == 0 {
+			next += dc
+		}
+		if next < 0 || next >= len(columns) {
+			return
+		}
+		c = next
+	}
+	if len(columns[c]) == 0 {
+		return
+	}
+	r = clamp(r+dr, 0, len(columns[c])-1)
+
+	m.selected = columns[c][r].Id
+	m.loadDetail()
+}
+
+// handleKey handles a key press and reports whether to quit
+func (m *model) handleKey(key rune) bool {
+	if m.prompt != nil {
+		m.handlePromptKey(key)
+		return false
+	}
+	m.message = ""
+
+	switch key {
+	case 'q', lineedit.KeyCtrlC:
+		return true
+	case lineedit.KeyTab, 'b':
+		if m.view == viewList {
+			m.view = viewBoard
+		} else {
+			m.view = viewList
+		}
+	case lineedit.KeyEnter:
+		if m.view == viewBoard {
+			m.view = viewList
+		} else {
+			m.showDetail = !m.showDetail
+		}
+	case lineedit.KeyEscape:
+		m.showDetail = false
+	case 'j', lineedit.KeyDown, lineedit.KeyCtrlN:
+		if m.view == viewBoard {
+			m.moveOnBoard(0, 1)
+		} else {
+			m.selectIndex(m.selectedIndex() + 1)
+		}
+	case 'k', lineedit.KeyUp, lineedit.KeyCtrlP:
+		if m.view == viewBoard {
+			m.moveOnBoard(0, -1)
+		} else {
+			m.selectIndex(m.selectedIndex() - 1)
+		}
+	case 'h', lineedit.KeyLeft:
+		if m.view == viewBoard {
+			m.moveOnBoard(-1, 0)
+		}
+	case 'l', lineedit.KeyRight:
+		if m.view == viewBoard {
+			m.moveOnBoard(1, 0)
+		}
+	case 'g', lineedit.KeyHome:
+		m.selectIndex(0)
+	case 'G', lineedit.KeyEnd:
+		m.selectIndex(len(m.tasks) - 1)
+	case 'r', lineedit.KeyCtrlL:
+		m.reload()
+	case '/':
+		m.prompt = &prompt{label: "Filter: ", value: []rune(m.filter), submit: m.setFilter}
+	case 'a':
+		m.prompt = &prompt{label: "New task: ", submit: m.addTask}
+	case 'm':
+		m.prompt = &prompt{label: "Switch to member: ", completions: m.memberNames(), submit: m.switchMember}
+	default:
+		m.handleTaskKey(key)
+	}
+	return false
+}
+
+// handleTaskKey handles the keys that act on the selected task
+func (m *model) handleTaskKey(key rune) {
+	t := m.selectedTask()
+	if t == nil {
+		switch key {
+		case 'd', 'e', 'D', 'c', 'u':
+			m.setMessage("No task selected")
+		}
+		return
+	}
+	id := t.Id
+
+	switch key {
+	case 'd':
+		if _, err := m.service.CompleteTask(m.ctx, id); err != nil {
+			m.setError(err)
+			return
+		}
+		m.setMessage("Task %d marked as done", id)
+		m.reload()
+	case 'e':
+		m.prompt = &prompt{
+			label: fmt.Sprintf("Rename task %d: ", id),
+			value: []rune(t.Name),
+			submit: func(name string) error {
+				return m.updateTask(task.UpdateTaskSchema{ID: id, Name: strings.TrimSpace(name)}, "Task %d renamed")
+			},
+		}
+	case 'c':
+		m.prompt = &prompt{
+			label:       fmt.Sprintf("Assign to task %d: ", id),
+			completions: m.memberNames(),
+			submit: func(names string) error {
+				if _, err := m.service.AssignTask(m.ctx, id, task.RoleAssignee, strings.Split(names, ",")); err != nil {
+					return err
+				}
+				m.setMessage("Task %d assigned", id)
+				m.reload()
+				return nil
+			},
+		}
+	case 'u':
+		m.prompt = &prompt{
+			label:       fmt.Sprintf("Unassign from task %d: ", id),
+			completions: t.Members(task.RoleAssignee, task.RoleReviewer, task.RoleWatcher),
+			submit: func(names string) error {
+				if _, err := m.service.UnassignTask(m.ctx, id, strings.Split(names, ",")); err != nil {
+					return err
+				}
+				m.setMessage("Task %d unassigned", id)
+				m.reload()
+				return nil
+			},
+		}
+	case 'D':
+		m.prompt = &prompt{
+			label:   fmt.Sprintf("Delete task %d %q? (y/n) ", id, t.Name),
+			confirm: true,
+			submit: func(string) error {
+				if err := m.service.DeleteTask(m.ctx, id); err != nil {
+					return err
+				}
+				m.setMessage("Task %d deleted", id)
+				m.reload()
+				return nil
+			},
+		}
+	}
+}
+
+func (m *model) setFilter(filter string) error {
+	// Check the filter before replacing the working one
+	if _, err := m.service.ListTasks(m.ctx, task.ListOptions{All: true, Filter: filter}); err != nil {
+		return err
+	}
+	m.filter = strings.TrimSpace(filter)
+	m.scroll = 0
+	m.reload()
+	return nil
+}
+
+func (m *model) addTask(name string) error {
+	added, err := m.service.AddTask(m.ctx, task.NewTaskSchema{Name: strings.TrimSpace(name)})
+	if err != nil {
+		return err
+	}
+	m.reload()
+	m.selected = added.Id
+	m.loadDetail()
+	m.setMessage("Task %d added", added.Id)
+	return nil
+}
+
+func (m *model) updateTask(data task.UpdateTaskSchema, message string) error {
+	if data.Name == "" {
+		return errors.New("nothing to change")
+	}
+	if _, err := m.service.UpdateTask(m.ctx, data); err != nil {
+		return err
+	}
+	m.setMessage(message, data.ID)
+	m.reload()
+	return nil
+}
+
+func (m *model) switchMember(name string) error {
+	if err := m.service.SetCurrentMember(m.ctx, name); err != nil {
+		return err
+	}
+	m.reload()
+	m.setMessage("Switched to %s", strings.TrimSpace(name))
+	return nil
+}
+
+// memberNames returns the names of the active members
+func (m *model) memberNames() []string {
+	var names []string
+	for _, member := range m.members {
+		if member.Active() {
+			names = append(names, member.Name)
+		}
+	}
+	return names
+}
+
+func clamp(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
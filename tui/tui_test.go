@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestReadKeysStopsWhenDone(t *testing.T) {
+	in, w := io.Pipe()
+	keys := make(chan rune)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		readKeys(in, keys, errs, done)
+		close(stopped)
+	}()
+
+	go w.Write([]byte("j"))
+	if key := <-keys; key != 'j' {
+		t.Fatalf("key = %q, want 'j'", key)
+	}
+
+	// Nobody receives the next key once the UI has quit
+	close(done)
+	go w.Write([]byte("k"))
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("readKeys didn't return after done was closed")
+	}
+}